@@ -0,0 +1,119 @@
+package redmine
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Kinds of ActivityEvent.
+const (
+	ActivityIssue     = "issue"
+	ActivityJournal   = "journal"
+	ActivityTimeEntry = "time_entry"
+)
+
+// ActivityEvent is a single event in a project's activity: the creation of an
+// issue, an update to an issue, or logged time.
+type ActivityEvent struct {
+	Kind  string
+	Time  time.Time
+	User  Identifier
+	Issue int
+	Title string
+	Notes string
+	Hours float64
+}
+
+// GetActivity returns the activity in a project between two dates, inclusive,
+// sorted from oldest to newest.
+//
+// The Redmine REST API has no activity endpoint, so the activity is
+// approximated from the issues updated since the start of the date range,
+// their journals, and the time entries spent in the range. Activity on an
+// issue is included even if the issue was updated again after the range. This
+// costs a request per issue updated since the start of the range. Unlike
+// Redmine's own activity view, time entries are placed at the start of the day
+// they were spent on, and changes the Session user isn't allowed to see are
+// omitted.
+func (session *Session) GetActivity(projectId int, from, to time.Time) ([]ActivityEvent, error) {
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, to.Location()).AddDate(0, 0, 1)
+	inRange := func(t time.Time) bool {
+		return !t.Before(start) && t.Before(end)
+	}
+
+	var events []ActivityEvent
+
+	issues, err := session.GetIssuesWithFilter(map[string]string{
+		"project_id": strconv.Itoa(projectId),
+		"status_id":  StatusAll,
+		"updated_on": ">=" + from.Format("2006-01-02")})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, issue := range issues {
+		title := fmt.Sprintf("%s #%d: %s", issue.Tracker.Name, issue.Id, issue.Subject)
+
+		if created, err := time.Parse(time.RFC3339, issue.CreatedOn); err == nil && inRange(created) {
+			events = append(events, ActivityEvent{
+				Kind:  ActivityIssue,
+				Time:  created,
+				User:  issue.Author,
+				Issue: issue.Id,
+				Title: title,
+				Notes: issue.Description,
+			})
+		}
+
+		detailed, err := session.GetIssueWithIncludes(issue.Id, "journals")
+		if err != nil {
+			return nil, err
+		}
+
+		for _, journal := range detailed.Journals {
+			created, err := time.Parse(time.RFC3339, journal.CreatedOn)
+			if err != nil || !inRange(created) {
+				continue
+			}
+			events = append(events, ActivityEvent{
+				Kind:  ActivityJournal,
+				Time:  created,
+				User:  journal.User,
+				Issue: issue.Id,
+				Title: title,
+				Notes: journal.Notes,
+			})
+		}
+	}
+
+	entries, err := session.TimeEntriesBetween(from, to, map[string]string{
+		"project_id": strconv.Itoa(projectId)})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		spent, err := time.ParseInLocation("2006-01-02", entry.SpentOn, from.Location())
+		if err != nil {
+			continue
+		}
+		events = append(events, ActivityEvent{
+			Kind:  ActivityTimeEntry,
+			Time:  spent,
+			User:  entry.User,
+			Issue: entry.Issue.Id,
+			Title: fmt.Sprintf("%.2f hours (%s)", entry.Hours, entry.Activity.Name),
+			Notes: entry.Comments,
+			Hours: entry.Hours,
+		})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+
+	return events, nil
+}
@@ -0,0 +1,94 @@
+package redmine
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Attachment represents a file attached to an issue, wiki page, or other
+// Redmine resource.
+type Attachment struct {
+	Id          int        `json:"id"`
+	Filename    string     `json:"filename"`
+	Filesize    int        `json:"filesize"`
+	ContentType string     `json:"content_type"`
+	Description string     `json:"description"`
+	ContentUrl  string     `json:"content_url"`
+	Author      Identifier `json:"author"`
+	CreatedOn   string     `json:"created_on"`
+}
+
+// GetAttachment returns the details of a specific attached file. The error
+// wraps ErrNotFound if the attachment doesn't exist.
+func (session *Session) GetAttachment(id int) (attachment Attachment, err error) {
+	var data []byte
+	if data, err = session.get("/attachments/"+strconv.Itoa(id)+".json", nil); err != nil {
+		return
+	}
+
+	var a struct {
+		Attachment Attachment `json:"attachment"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err = dec.Decode(&a); err != nil {
+		return
+	}
+	attachment = a.Attachment
+	return
+}
+
+// GetIssueAttachments returns the files attached to an issue. The returned
+// slice is empty, rather than nil, if the issue has no attachments.
+func (session *Session) GetIssueAttachments(issueId int) ([]Attachment, error) {
+	issue, err := session.GetIssueWithIncludes(issueId, "attachments")
+	if err != nil {
+		return nil, err
+	}
+	if issue.Attachments == nil {
+		return []Attachment{}, nil
+	}
+	return issue.Attachments, nil
+}
+
+// DownloadAttachment writes the contents of an attached file to w. The file is
+// always downloaded from the Session's Redmine URL rather than from
+// attachment.ContentUrl, which Redmine builds from its configured host name and
+// may point elsewhere, so the Session's credentials are never sent to another
+// server.
+func (session *Session) DownloadAttachment(attachment Attachment, w io.Writer) error {
+	downloadUrl := session.url + "/attachments/download/" + strconv.Itoa(attachment.Id) +
+		"/" + url.PathEscape(attachment.Filename)
+	req, err := session.newRequest("GET", downloadUrl, nil)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	resp, err := session.do(req, downloadUrl)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		content, _ := ioutil.ReadAll(resp.Body)
+		return session.responseError(resp, content)
+	}
+
+	_, err = io.Copy(w, resp.Body)
+
+	if session.onResponse != nil {
+		// The body has been consumed, so the hook gets an empty one
+		hookResp := *resp
+		hookResp.Body = http.NoBody
+		session.onResponse(&hookResp, time.Since(start))
+	}
+
+	return err
+}
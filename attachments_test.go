@@ -0,0 +1,34 @@
+package redmine
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+)
+
+func TestDownloadAttachmentUsesSessionUrl(t *testing.T) {
+	session := NewFixtureSession(Fixtures{
+		"/attachments/download/7/report.pdf": "contents",
+	})
+	attachment := Attachment{
+		Id:         7,
+		Filename:   "report.pdf",
+		ContentUrl: "http://elsewhere.test/attachments/download/7/report.pdf",
+	}
+
+	var host string
+	session.SetRequestHook(func(req *http.Request) {
+		host = req.URL.Host
+	})
+
+	var buf bytes.Buffer
+	if err := session.DownloadAttachment(attachment, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if host != "redmine.test" {
+		t.Errorf("downloaded from %s, want redmine.test", host)
+	}
+	if buf.String() != "contents" {
+		t.Errorf("got %q, want %q", buf.String(), "contents")
+	}
+}
@@ -0,0 +1,71 @@
+package redmine
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+)
+
+// IssueCategory represents an issue category in a Redmine project. AssignedTo
+// is the user new issues in the category are assigned to by default, if any.
+type IssueCategory struct {
+	Id         int        `json:"id"`
+	Project    Identifier `json:"project"`
+	Name       string     `json:"name"`
+	AssignedTo Identifier `json:"assigned_to,omitempty"`
+}
+
+// UpdateIssueCategory is used to pass issue category updates to Redmine.
+type UpdateIssueCategory struct {
+	Name       string `json:"name,omitempty"`
+	AssignedTo int    `json:"assigned_to_id,omitempty"`
+}
+
+// GetIssueCategories returns the issue categories of a project.
+func (session *Session) GetIssueCategories(projectId int) ([]IssueCategory, error) {
+	var categories []IssueCategory
+	path := "/projects/" + strconv.Itoa(projectId) + "/issue_categories.json"
+	if err := session.getAll(path, nil, "issue_categories", &categories); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// GetIssueCategory returns a specific issue category.
+func (session *Session) GetIssueCategory(id int) (category IssueCategory, err error) {
+	var data []byte
+	if data, err = session.get("/issue_categories/"+strconv.Itoa(id)+".json", nil); err != nil {
+		return
+	}
+
+	var c struct {
+		IssueCategory IssueCategory `json:"issue_category"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err = dec.Decode(&c); err != nil {
+		return
+	}
+	category = c.IssueCategory
+	return
+}
+
+// UpdateIssueCategory updates an issue category.
+func (session *Session) UpdateIssueCategory(id int, category UpdateIssueCategory) error {
+	data := map[string]interface{}{
+		"issue_category": category,
+	}
+	_, err := session.put("/issue_categories/"+strconv.Itoa(id)+".json", data)
+	return err
+}
+
+// DeleteIssueCategory deletes an issue category. If reassignToId isn't 0, the
+// issues in the category are moved to the category with that id; otherwise
+// they're left without a category.
+func (session *Session) DeleteIssueCategory(id int, reassignToId int) error {
+	path := "/issue_categories/" + strconv.Itoa(id) + ".json"
+	if reassignToId != 0 {
+		path += "?" + toQueryString(map[string]string{"reassign_to_id": strconv.Itoa(reassignToId)})
+	}
+	_, err := session.delete(path)
+	return err
+}
@@ -0,0 +1,62 @@
+package redmine
+
+// Client is a higher-level wrapper around a Session for applications that
+// display Redmine data. All of the Session's methods are available on a
+// Client; the Client's own methods return issues with their names filled in
+// and read rarely changing data, such as issue statuses, from the Session's
+// caches.
+type Client struct {
+	*Session
+}
+
+// NewClient returns a Client that uses session to communicate with Redmine.
+func NewClient(session Session) *Client {
+	return &Client{Session: &session}
+}
+
+// Issues returns an array of all the issues matching the given filter
+// parameters, with their names resolved.
+func (client *Client) Issues(filter map[string]string) ([]Issue, error) {
+	issues, err := client.GetIssuesWithFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	if err = client.ResolveNames(issues); err != nil {
+		return nil, err
+	}
+	return issues, nil
+}
+
+// Issue returns a specific issue along with the associated data named by
+// includes, with its names resolved.
+func (client *Client) Issue(id int, includes ...string) (Issue, error) {
+	issue, err := client.GetIssueWithIncludes(id, includes...)
+	if err != nil {
+		return issue, err
+	}
+
+	issues := []Issue{issue}
+	err = client.ResolveNames(issues)
+	return issues[0], err
+}
+
+// IssueStatuses returns the available issue statuses.
+func (client *Client) IssueStatuses() ([]IssueStatus, error) {
+	return client.cachedIssueStatuses()
+}
+
+// IssuePriorities returns the available issue priorities.
+func (client *Client) IssuePriorities() ([]IssuePriority, error) {
+	return client.cachedIssuePriorities()
+}
+
+// Trackers returns the available trackers.
+func (client *Client) Trackers() ([]Tracker, error) {
+	return client.cachedTrackers()
+}
+
+// UserName returns the display name of a user, or an empty string if the user
+// isn't visible to the Session user.
+func (client *Client) UserName(id int) (string, error) {
+	return client.userName(id)
+}
@@ -0,0 +1,64 @@
+package redmine
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// issueColumns maps the column names accepted by ExportIssuesCSV to the
+// functions that produce their values.
+var issueColumns = map[string]func(Issue) string{
+	"id":              func(i Issue) string { return strconv.Itoa(i.Id) },
+	"project":         func(i Issue) string { return i.Project.Name },
+	"tracker":         func(i Issue) string { return i.Tracker.Name },
+	"status":          func(i Issue) string { return i.Status.Name },
+	"priority":        func(i Issue) string { return i.Priority.Name },
+	"subject":         func(i Issue) string { return i.Subject },
+	"description":     func(i Issue) string { return i.Description },
+	"author":          func(i Issue) string { return i.Author.Name },
+	"assigned_to":     func(i Issue) string { return i.AssignedTo.Name },
+	"category":        func(i Issue) string { return i.Category.Name },
+	"start_date":      func(i Issue) string { return i.StartDate },
+	"due_date":        func(i Issue) string { return i.DueDate },
+	"done_ratio":      func(i Issue) string { return strconv.Itoa(i.DoneRatio) },
+	"estimated_hours": func(i Issue) string { return strconv.FormatFloat(i.EstimatedHours, 'f', -1, 64) },
+	"created_on":      func(i Issue) string { return i.CreatedOn },
+	"updated_on":      func(i Issue) string { return i.UpdatedOn },
+}
+
+// ExportIssuesCSV writes the issues matching the given filter parameters to w
+// as CSV, one page of issues at a time. The first row is a header containing
+// the column names. Valid columns are id, project, tracker, status, priority,
+// subject, description, author, assigned_to, category, start_date, due_date,
+// done_ratio, estimated_hours, created_on, and updated_on.
+func (session *Session) ExportIssuesCSV(w io.Writer, filter map[string]string, columns []string) error {
+	values := make([]func(Issue) string, len(columns))
+	for i, column := range columns {
+		value, ok := issueColumns[column]
+		if !ok {
+			return fmt.Errorf("unknown column %s", column)
+		}
+		values[i] = value
+	}
+
+	out := csv.NewWriter(w)
+	if err := out.Write(columns); err != nil {
+		return err
+	}
+
+	row := make([]string, len(columns))
+	err := session.EachIssue(filter, func(issue Issue) error {
+		for i, value := range values {
+			row[i] = value(issue)
+		}
+		return out.Write(row)
+	})
+	if err != nil {
+		return err
+	}
+
+	out.Flush()
+	return out.Error()
+}
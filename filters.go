@@ -0,0 +1,208 @@
+package redmine
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A FilterOption adds a filter to a set of query parameters.
+type FilterOption func(params map[string]string)
+
+// ApplyFilters adds filters to a set of query parameters, creating the set if
+// params is nil, and returns it.
+func ApplyFilters(params map[string]string, filters ...FilterOption) map[string]string {
+	if params == nil {
+		params = map[string]string{}
+	}
+	for _, filter := range filters {
+		filter(params)
+	}
+	return params
+}
+
+// WithCustomFieldFilter filters on the value of a custom field. If several
+// values are given, items with any of the values match, which is also how to
+// match a custom field that has multiple values.
+func WithCustomFieldFilter(fieldId int, values ...string) FilterOption {
+	return func(params map[string]string) {
+		params["cf_"+strconv.Itoa(fieldId)] = strings.Join(values, "|")
+	}
+}
+
+// CreatedBetween matches items created between two dates, inclusive. Only the
+// calendar dates of from and to, in their own locations, are used.
+func CreatedBetween(from, to time.Time) FilterOption {
+	return func(params map[string]string) {
+		params["created_on"] = dateRange(from, to)
+	}
+}
+
+// Redmine's list filters, such as "status_id", "tracker_id", and
+// "assigned_to_id", match any of several values separated by "|". The
+// "issue_id" filter is an exception that takes a comma-separated list of ids
+// instead, and treats "|" as part of an id. The "project_id" parameter isn't a
+// list filter at all: Redmine looks it up as a single project, so neither
+// separator matches several projects, and "1|2" is answered with a 404.
+
+// FieldIn matches items whose value for a list filter, such as "tracker_id",
+// is any of the given ids.
+func FieldIn(field string, ids ...int) FilterOption {
+	return func(params map[string]string) {
+		params[field] = joinIds(ids, "|")
+	}
+}
+
+// StatusIn matches issues with any of the given statuses.
+func StatusIn(ids ...int) FilterOption {
+	return FieldIn("status_id", ids...)
+}
+
+// IssueIdIn matches the issues with the given ids.
+func IssueIdIn(ids ...int) FilterOption {
+	return func(params map[string]string) {
+		params["issue_id"] = joinIds(ids, ",")
+	}
+}
+
+// joinIds returns ids joined by sep.
+func joinIds(ids []int, sep string) string {
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = strconv.Itoa(id)
+	}
+	return strings.Join(values, sep)
+}
+
+// TextOperator is an operator for filtering on a text field, such as an
+// issue's subject or description.
+type TextOperator string
+
+// Operators for TextFilter. Redmine also treats a value with no operator as
+// an exact match, a value of "*" as any non-empty text, and a value of "!*"
+// as empty text.
+const (
+	TextContains    TextOperator = "~"
+	TextNotContains TextOperator = "!~"
+	TextStartsWith  TextOperator = "^"
+	TextEndsWith    TextOperator = "$"
+)
+
+// TextFilter filters on a text field, as in TextFilter("subject",
+// TextStartsWith, "[CI]"). Redmine matches text without regard to case.
+func TextFilter(field string, op TextOperator, text string) FilterOption {
+	return func(params map[string]string) {
+		params[field] = string(op) + text
+	}
+}
+
+// SubjectContains matches issues whose subject contains text.
+func SubjectContains(text string) FilterOption {
+	return TextFilter("subject", TextContains, text)
+}
+
+// DescriptionContains matches issues whose description contains text.
+func DescriptionContains(text string) FilterOption {
+	return TextFilter("description", TextContains, text)
+}
+
+// SortOrder is the direction in which a list is sorted.
+type SortOrder string
+
+// Sort orders for IssueFilter.Sort.
+const (
+	Asc  SortOrder = "asc"
+	Desc SortOrder = "desc"
+)
+
+// IssueFilter builds the filter parameters for listing issues, as in
+//
+//	NewIssueFilter().Project(5).Status(StatusOpen).Sort("priority", Desc).Build()
+//
+// The parameters it builds are the same as those that could be written by
+// hand, and can be passed to any method that takes issue filter parameters.
+type IssueFilter struct {
+	params map[string]string
+}
+
+// NewIssueFilter returns an empty IssueFilter.
+func NewIssueFilter() *IssueFilter {
+	return &IssueFilter{params: map[string]string{}}
+}
+
+// Project matches issues in a project or its subprojects.
+func (filter *IssueFilter) Project(id int) *IssueFilter {
+	filter.params["project_id"] = strconv.Itoa(id)
+	return filter
+}
+
+// Status matches issues by status, which is StatusOpen, StatusClosed,
+// StatusAll, or the id of a status.
+func (filter *IssueFilter) Status(status string) *IssueFilter {
+	filter.params["status_id"] = status
+	return filter
+}
+
+// StatusId matches issues with a specific status.
+func (filter *IssueFilter) StatusId(id int) *IssueFilter {
+	return filter.Status(strconv.Itoa(id))
+}
+
+// Tracker matches issues with a specific tracker.
+func (filter *IssueFilter) Tracker(id int) *IssueFilter {
+	filter.params["tracker_id"] = strconv.Itoa(id)
+	return filter
+}
+
+// AssignedTo matches issues assigned to a specific user or group.
+func (filter *IssueFilter) AssignedTo(id int) *IssueFilter {
+	filter.params["assigned_to_id"] = strconv.Itoa(id)
+	return filter
+}
+
+// AssignedToMe matches issues assigned to the Session user.
+func (filter *IssueFilter) AssignedToMe() *IssueFilter {
+	filter.params["assigned_to_id"] = "me"
+	return filter
+}
+
+// Author matches issues created by a specific user.
+func (filter *IssueFilter) Author(id int) *IssueFilter {
+	filter.params["author_id"] = strconv.Itoa(id)
+	return filter
+}
+
+// UpdatedSince matches issues updated at or after a specific time.
+func (filter *IssueFilter) UpdatedSince(t time.Time) *IssueFilter {
+	filter.params["updated_on"] = ">=" + t.UTC().Format("2006-01-02T15:04:05Z")
+	return filter
+}
+
+// Sort sorts issues by a field, such as "priority" or "updated_on". Sorting by
+// several fields is done by calling Sort once for each, starting with the
+// most significant.
+func (filter *IssueFilter) Sort(field string, order SortOrder) *IssueFilter {
+	if order == Desc {
+		field += ":desc"
+	}
+	if sort := filter.params["sort"]; sort != "" {
+		field = sort + "," + field
+	}
+	filter.params["sort"] = field
+	return filter
+}
+
+// Where adds other filters, such as WithCustomFieldFilter.
+func (filter *IssueFilter) Where(filters ...FilterOption) *IssueFilter {
+	ApplyFilters(filter.params, filters...)
+	return filter
+}
+
+// Build returns the filter parameters.
+func (filter *IssueFilter) Build() map[string]string {
+	params := map[string]string{}
+	for key, value := range filter.params {
+		params[key] = value
+	}
+	return params
+}
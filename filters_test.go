@@ -0,0 +1,53 @@
+package redmine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreatedBetween(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"issues":[],"total_count":0,"offset":0,"limit":100}`))
+	}))
+	defer server.Close()
+
+	session := OpenSession(server.URL, "key")
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 31, 23, 59, 0, 0, time.UTC)
+	if _, err := session.GetIssuesWithFilter(ApplyFilters(nil, CreatedBetween(from, to))); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "created_on=%3E%3C2024-01-01%7C2024-01-31"
+	if !strings.Contains(query, want) {
+		t.Errorf("got query %q, want it to contain %q", query, want)
+	}
+}
+
+func TestMultiValueFilters(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"issues":[],"total_count":0,"offset":0,"limit":100}`))
+	}))
+	defer server.Close()
+
+	session := OpenSession(server.URL, "key")
+	filter := ApplyFilters(nil, StatusIn(1, 2), FieldIn("tracker_id", 3), IssueIdIn(4, 5))
+	if _, err := session.GetIssuesWithFilter(filter); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"status_id=1%7C2", "tracker_id=3", "issue_id=4%2C5"} {
+		if !strings.Contains(query, want) {
+			t.Errorf("got query %q, want it to contain %q", query, want)
+		}
+	}
+}
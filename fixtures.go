@@ -0,0 +1,54 @@
+package redmine
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+)
+
+// Fixtures maps Redmine request paths, such as "/issues/1.json", to the JSON
+// that should be returned for them. A path may be prefixed with a method and a
+// space, as in "PUT /issues/1.json", to only match requests using that method.
+// Query strings are ignored when matching requests.
+//
+// Fixtures is an http.RoundTripper, so it can also be used as the Transport of
+// a client passed to Session.SetHttpClient.
+type Fixtures map[string]string
+
+// RoundTrip answers a request using the fixture for its path, or with a 404
+// response if there isn't one.
+func (fixtures Fixtures) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	content, ok := fixtures[req.Method+" "+req.URL.Path]
+	if !ok {
+		content, ok = fixtures[req.URL.Path]
+	}
+
+	resp := &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"Content-Type": {"application/json; charset=utf-8"}},
+		Body:       ioutil.NopCloser(bytes.NewBufferString(content)),
+		Request:    req,
+	}
+	if !ok {
+		resp.Status = "404 Not Found"
+		resp.StatusCode = http.StatusNotFound
+	}
+
+	return resp, nil
+}
+
+// NewFixtureSession returns a Session that answers all requests from fixtures
+// rather than a Redmine server, for testing code that uses this package.
+func NewFixtureSession(fixtures Fixtures) Session {
+	session := OpenSession("http://redmine.test", "fixture")
+	session.SetHttpClient(&http.Client{Transport: fixtures})
+	return session
+}
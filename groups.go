@@ -0,0 +1,89 @@
+package redmine
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// Group represents a group of users in Redmine.
+type Group struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// GetGroups returns all groups. Listing groups requires administrator
+// privileges.
+func (session *Session) GetGroups() ([]Group, error) {
+	var groups []Group
+	if err := session.getAll("/groups.json", nil, "groups", &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// GetGroupIssues returns all issues assigned to a group that match the given
+// filter parameters. Like GetIssuesWithFilter, it only returns open issues
+// unless the filter sets "status_id".
+func (session *Session) GetGroupIssues(groupId int, filter map[string]string) ([]Issue, error) {
+	params := map[string]string{}
+	for key, value := range filter {
+		params[key] = value
+	}
+	params["assigned_to_id"] = strconv.Itoa(groupId)
+	return session.GetIssuesWithFilter(params)
+}
+
+// IsGroup reports whether the principal with the given id, such as an issue's
+// AssignedTo, is a group rather than a user. Users and groups never share ids
+// in Redmine, so this only needs the list of groups, which is requested the
+// first time it's needed and requires administrator privileges.
+func (session *Session) IsGroup(id int) (bool, error) {
+	groups, err := session.cachedGroups()
+	if err != nil {
+		return false, err
+	}
+
+	for _, group := range groups {
+		if group.Id == id {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// cachedGroups returns all groups, only requesting them from Redmine the first
+// time they're needed. If the Session user isn't allowed to list groups, the
+// error is kept for userListTTL, as the users listed for ResolveNames are.
+func (session *Session) cachedGroups() ([]Group, error) {
+	session.cache.Lock()
+	groups := session.cache.groups
+	denied := session.cache.groupsDenied
+	deniedAt := session.cache.groupsDeniedAt
+	session.cache.Unlock()
+	if groups != nil {
+		return groups, nil
+	}
+	if denied != nil && time.Since(deniedAt) < userListTTL {
+		return nil, denied
+	}
+
+	groups, err := session.unpaged().GetGroups()
+	if errors.Is(err, ErrForbidden) {
+		session.cache.Lock()
+		session.cache.groupsDenied = err
+		session.cache.groupsDeniedAt = time.Now()
+		session.cache.Unlock()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if groups == nil {
+		groups = []Group{}
+	}
+
+	session.cache.Lock()
+	session.cache.groups = groups
+	session.cache.Unlock()
+	return groups, nil
+}
@@ -2,35 +2,150 @@
 
 Package redmine provides an API for interacting with a Redmine server.
 
-Note that this is a read-only API. There is not currently any support for
-updating information in Redmine.
+Most of the API is for reading information from Redmine, with support for
+updating issues and logging time.
 
 */
 package redmine
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
+	"mime"
 	"net/http"
 	"net/url"
+	"reflect"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
 var client = &http.Client{}
 
+// DefaultUserAgent is the User-Agent header a Session sends unless it's given
+// another with SetUserAgent.
+const DefaultUserAgent = "go-redmine/0.1"
+
+// defaultMaxResponseBytes is the size a response body may have unless a
+// Session is given another limit with SetMaxResponseBytes.
+const defaultMaxResponseBytes = 16 << 20
+
+// Values accepted by the "status_id" issue filter, in addition to the ids of
+// specific statuses. When no status is given, Redmine only returns open
+// issues.
+const (
+	StatusOpen   = "open"
+	StatusClosed = "closed"
+	StatusAll    = "*"
+)
+
+// ErrNotFound is wrapped by the error returned when a requested resource
+// doesn't exist.
+var ErrNotFound = errors.New("redmine: resource not found")
+
+// ErrUnauthorized is wrapped by the error returned when Redmine rejects a
+// Session's credentials.
+var ErrUnauthorized = errors.New("redmine: invalid credentials")
+
+// ErrForbidden is wrapped by the error returned when the Session user isn't
+// allowed to access a resource, such as when listing users without
+// administrator privileges.
+var ErrForbidden = errors.New("redmine: access forbidden")
+
+// ErrUnsupported is returned for operations the Redmine REST API doesn't
+// provide.
+var ErrUnsupported = errors.New("redmine: operation not supported")
+
 // structures ///////////////////////////
 
 // Session represents an active connection to a Redmine server.
+//
+// A Session's methods may be called from multiple goroutines, and copies of a
+// Session share the data it has cached. The methods that configure a Session,
+// such as SetHeader, aren't safe for concurrent use and should be called
+// before the Session is shared.
 type Session struct {
-	username string
-	password string
-	url      string
-	apiKey   string
+	username  string
+	password  string
+	url       string
+	apiKey    string
+	headers   http.Header
+	userAgent string
+
+	authMode   AuthMode
+	switchUser string
+	dryRun     bool
+
+	defaultProject int
+	validate       bool
+	concurrency    int
+	maxPages       int
+	pageSize       int
+
+	maxResponseBytes int64
+
+	onRequest  func(*http.Request)
+	onResponse func(*http.Response, time.Duration)
+	responses  ResponseCache
+
+	location *time.Location
+
+	ctx    context.Context
+	client *http.Client
+	cache  *sessionCache
+}
+
+// AuthMode determines how a Session sends its API key to Redmine.
+type AuthMode int
+
+const (
+	// AuthHeader sends the API key in the X-Redmine-API-Key header. This is
+	// the default.
+	AuthHeader AuthMode = iota
+
+	// AuthQueryParam sends the API key in the "key" query parameter, for
+	// servers behind proxies that strip custom headers.
+	AuthQueryParam
+)
+
+// sessionCache holds data looked up from Redmine that rarely changes. It's
+// shared by all copies of a Session.
+type sessionCache struct {
+	sync.Mutex
+	statuses     []IssueStatus
+	priorities   []IssuePriority
+	trackers     []Tracker
+	activities   []TimeEntryActivity
+	groups       []Group
+	customFields []CustomField
+	users        map[int]User
+	logins       map[string]int
+	projects     map[int]Project
+
+	// usersListed is when users was last filled by listing all users
+	usersListed time.Time
+
+	// groupsDenied is the error, wrapping ErrForbidden, returned when groups
+	// were last listed, at groupsDeniedAt
+	groupsDenied   error
+	groupsDeniedAt time.Time
+
+	// projectList is all projects as of projectsListed
+	projectList    []Project
+	projectsListed time.Time
+
+	// currentUserId is the id of the user whose credentials, along with
+	// the impersonated user if any, are currentUserAuth
+	currentUserId   int
+	currentUserAuth string
 }
 
 // User represents a Redmine user.
@@ -38,58 +153,220 @@ type User struct {
 	Id          int    `json:"id"`
 	ApiKey      string `json:"api_key"`
 	Login       string `json:"login"`
+	Firstname   string `json:"firstname"`
+	Lastname    string `json:"lastname"`
 	Mail        string `json:"mail"`
 	LastLoginOn string `json:"last_login_on"`
+
+	Memberships []Membership `json:"memberships,omitempty"`
 }
 
-// Project represents a Redmine project.
+// Project represents a Redmine project. EnabledModules, IssueCategories,
+// IssueCustomFields, and Trackers are only set when the project is retrieved
+// with the corresponding includes. CustomFields holds the values of the
+// project custom fields visible to the Session user.
 type Project struct {
-	CreatedOn   string `json:"created_on"`
-	Description string `json:"description"`
-	Id          int    `json:"id"`
-	IsPublic    bool   `json:"is_public"`
+	CreatedOn         string       `json:"created_on"`
+	CustomFields      []ValueField `json:"custom_fields,omitempty"`
+	Description       string       `json:"description"`
+	EnabledModules    []Identifier `json:"enabled_modules,omitempty"`
+	Id                int          `json:"id"`
+	IsPublic          bool         `json:"is_public"`
+	IssueCategories   []Identifier `json:"issue_categories,omitempty"`
+	IssueCustomFields []Identifier `json:"issue_custom_fields,omitempty"`
+	Name              string       `json:"name"`
+	Parent            Identifier   `json:"parent,omitempty"`
+	Trackers          []Identifier `json:"trackers,omitempty"`
+	UpdatedOn         string       `json:"updated_on"`
+}
+
+// NewProject is used to create projects in Redmine. Setting Parent creates a
+// subproject of the project with that id.
+type NewProject struct {
 	Name        string `json:"name"`
-	UpdatedOn   string `json:"updated_on"`
+	Identifier  string `json:"identifier"`
+	Description string `json:"description,omitempty"`
+	IsPublic    *bool  `json:"is_public,omitempty"`
+	Parent      int    `json:"parent_id,omitempty"`
 }
 
-// Issue represents a single issue in Redmine.
+// HasModule returns true if the named module, such as "time_tracking" or
+// "wiki", is enabled for a project retrieved with the "enabled_modules"
+// include.
+func (project Project) HasModule(name string) bool {
+	for _, module := range project.EnabledModules {
+		if module.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Issue represents a single issue in Redmine. AllowedStatuses, the statuses
+// the Session user may change the issue to, is only set when the issue is
+// retrieved with the "allowed_statuses" include from Redmine 5.0 or later.
+// The Total fields are computed by Redmine and include the issue's subtasks.
 type Issue struct {
-	AssignedTo     Identifier   `json:"assigned_to,omitempty"`
-	Author         Identifier   `json:"author,omitempty"`
-	Category       Identifier   `json:"category,omitempty"`
+	AllowedStatuses     []IssueStatus `json:"allowed_statuses,omitempty"`
+	AssignedTo          Identifier    `json:"assigned_to,omitempty"`
+	Attachments         []Attachment  `json:"attachments,omitempty"`
+	Author              Identifier    `json:"author,omitempty"`
+	Category            Identifier    `json:"category,omitempty"`
+	Children            []Issue       `json:"children,omitempty"`
+	ClosedOn            string        `json:"closed_on,omitempty"`
+	CreatedOn           string        `json:"created_on,omitempty"`
+	CustomFields        []ValueField  `json:"custom_fields,omitempty"`
+	Description         string        `json:"description,omitempty"`
+	DoneRatio           int           `json:"done_ratio,omitempty"`
+	DueDate             string        `json:"due_date,omitempty"`
+	EstimatedHours      float64       `json:"estimated_hours,omitempty"`
+	FixedVersion        Identifier    `json:"fixed_version,omitempty"`
+	Id                  int           `json:"id,omitempty"`
+	IsPrivate           bool          `json:"is_private,omitempty"`
+	Journals            []Journal     `json:"journals,omitempty"`
+	Priority            Identifier    `json:"priority,omitempty"`
+	Project             Identifier    `json:"project,omitempty"`
+	Relations           []Relation    `json:"relations,omitempty"`
+	SpentHours          float64       `json:"spent_hours"`
+	StartDate           string        `json:"start_date,omitempty"`
+	Status              IssueStatus   `json:"status,omitempty"`
+	Subject             string        `json:"subject,omitempty"`
+	TotalEstimatedHours float64       `json:"total_estimated_hours"`
+	TotalSpentHours     float64       `json:"total_spent_hours"`
+	Tracker             Identifier    `json:"tracker,omitempty"`
+	UpdatedOn           string        `json:"updated_on,omitempty"`
+	Watchers            []Identifier  `json:"watchers,omitempty"`
+
+	// AuthorMail and AssignedToMail are the mail addresses of the issue's
+	// author and assignee, which are only set by Session.EnrichContacts.
+	AuthorMail     string `json:"-"`
+	AssignedToMail string `json:"-"`
+
+	// Raw holds every field of the issue as it was returned by Redmine,
+	// including fields added by plugins or newer versions of Redmine that
+	// Issue doesn't otherwise have.
+	Raw map[string]json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON decodes an Issue, also storing its raw fields in Raw.
+func (issue *Issue) UnmarshalJSON(data []byte) error {
+	type issueFields Issue
+	var fields issueFields
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	*issue = Issue(fields)
+	return json.Unmarshal(data, &issue.Raw)
+}
+
+// UpdateIssue is used to pass updates to Redmine.
+//
+// Fields with zero values are normally left out of an update. To set a field
+// to its zero value, such as setting DoneRatio back to 0, add the field's JSON
+// name ("done_ratio") to ForceSend. Empty dates and ids named in ForceSend are
+// sent as null, which clears them, so a due date is removed with
+//
+//	UpdateIssue{ForceSend: []string{"due_date"}}
+//
+// IsPrivate is a pointer so that an issue can be made public by pointing it
+// at false; leaving it nil leaves the issue's privacy unchanged.
+type UpdateIssue struct {
+	AssignedTo     int          `json:"assigned_to_id,omitempty"`
+	Author         int          `json:"author_id,omitempty"`
+	Category       int          `json:"category_id,omitempty"`
 	CreatedOn      string       `json:"created_on,omitempty"`
 	CustomFields   []ValueField `json:"custom_fields,omitempty"`
 	Description    string       `json:"description,omitempty"`
 	DoneRatio      int          `json:"done_ratio,omitempty"`
 	DueDate        string       `json:"due_date,omitempty"`
 	EstimatedHours float64      `json:"estimated_hours,omitempty"`
-	Id             int          `json:"id,omitempty"`
-	Priority       Identifier   `json:"priority,omitempty"`
-	Project        Identifier   `json:"project,omitempty"`
+	FixedVersion   int          `json:"fixed_version_id,omitempty"`
+	IsPrivate      *bool        `json:"is_private,omitempty"`
+	Notes          string       `json:"notes,omitempty"`
+	Priority       int          `json:"priority_id,omitempty"`
+	Project        int          `json:"project_id,omitempty"`
 	StartDate      string       `json:"start_date,omitempty"`
-	Status         IssueStatus  `json:"status,omitempty"`
+	Status         int          `json:"status_id,omitempty"`
 	Subject        string       `json:"subject,omitempty"`
-	Tracker        Identifier   `json:"tracker,omitempty"`
+	Tracker        int          `json:"tracker_id,omitempty"`
 	UpdatedOn      string       `json:"updated_on,omitempty"`
+
+	ForceSend []string `json:"-"`
 }
 
-// UpdateIssue is used to pass updates to Redmine.
-type UpdateIssue struct {
-	AssignedTo     int     `json:"assigned_to_id,omitempty"`
-	Author         int     `json:"author_id,omitempty"`
-	Category       int     `json:"category_id,omitempty"`
-	CreatedOn      string  `json:"created_on,omitempty"`
-	Description    string  `json:"description,omitempty"`
-	DoneRatio      int     `json:"done_ratio,omitempty"`
-	DueDate        string  `json:"due_date,omitempty"`
-	EstimatedHours float64 `json:"estimated_hours,omitempty"`
-	Priority       int     `json:"priority_id,omitempty"`
-	Project        int     `json:"project_id,omitempty"`
-	StartDate      string  `json:"start_date,omitempty"`
-	Status         int     `json:"status_id,omitempty"`
-	Subject        string  `json:"subject,omitempty"`
-	Tracker        int     `json:"tracker_id,omitempty"`
-	UpdatedOn      string  `json:"updated_on,omitempty"`
+// MarshalJSON encodes an UpdateIssue, including any zero-valued fields named
+// in ForceSend.
+func (issue UpdateIssue) MarshalJSON() ([]byte, error) {
+	type updateIssue UpdateIssue
+	return marshalWithZeroes(updateIssue(issue), issue.ForceSend)
+}
+
+// Journal represents an entry in an issue's history, which is returned when
+// an issue is retrieved with the "journals" include.
+type Journal struct {
+	Id           int             `json:"id"`
+	User         Identifier      `json:"user"`
+	Notes        string          `json:"notes"`
+	PrivateNotes bool            `json:"private_notes"`
+	CreatedOn    string          `json:"created_on"`
+	Details      []JournalDetail `json:"details"`
+}
+
+// IsWatchedBy reports whether a user watches an issue. Watchers are only set
+// when the issue is retrieved with the "watchers" include, which is only
+// allowed for single issues; for lists of issues, WatchedIssueIds finds the
+// issues the Session user watches with fewer requests.
+func (issue Issue) IsWatchedBy(userId int) bool {
+	return hasIdentifier(issue.Watchers, userId)
+}
+
+// ResolutionDuration returns the time between an issue's creation and when it
+// was last closed. It returns false if the issue has never been closed.
+func (issue Issue) ResolutionDuration() (time.Duration, bool) {
+	created, err := ParseTime(issue.CreatedOn)
+	if err != nil || created.IsZero() {
+		return 0, false
+	}
+	closed, err := ParseTime(issue.ClosedOn)
+	if err != nil || closed.IsZero() {
+		return 0, false
+	}
+	return closed.Sub(created.Time), true
+}
+
+// PublicJournals returns the journals of an issue that don't have private
+// notes. Private notes are only returned to users allowed to see them, so
+// views shown to other users should use these journals.
+func (issue Issue) PublicJournals() []Journal {
+	var journals []Journal
+	for _, journal := range issue.Journals {
+		if !journal.PrivateNotes {
+			journals = append(journals, journal)
+		}
+	}
+	return journals
+}
+
+// JournalDetail describes a single change recorded in a Journal.
+type JournalDetail struct {
+	Property string `json:"property"`
+	Name     string `json:"name"`
+	OldValue string `json:"old_value"`
+	NewValue string `json:"new_value"`
+}
+
+// Relation represents a relation between two issues, which is returned when
+// an issue is retrieved with the "relations" include. Type is a relation type
+// such as "relates", "blocks", or "precedes", read as "Issue Type IssueTo".
+// Delay is the number of days between preceding and following issues, and is
+// only set for "precedes" and "follows" relations.
+type Relation struct {
+	Id      int    `json:"id"`
+	Issue   int    `json:"issue_id"`
+	IssueTo int    `json:"issue_to_id"`
+	Type    string `json:"relation_type"`
+	Delay   int    `json:"delay"`
 }
 
 // IssueStatus represents one of the issue statuses configured in Redmine.
@@ -102,29 +379,149 @@ type IssueStatus struct {
 
 // TimeEntry represents a single time entry.
 type TimeEntry struct {
-	Id        int        `json:"id"`
-	Hours     float64    `json:"hours"`
-	CreatedOn string     `json:"created_on"`
-	SpentOn   string     `json:"spent_on"`
-	UpdatedOn string     `json:"updated_on"`
-	User      Identifier `json:"user"`
-	Project   Identifier `json:"project"`
-	Activity  Identifier `json:"activity"`
-	Issue     struct {
+	Id           int          `json:"id"`
+	Hours        float64      `json:"hours"`
+	Comments     string       `json:"comments"`
+	CreatedOn    string       `json:"created_on"`
+	SpentOn      string       `json:"spent_on"`
+	UpdatedOn    string       `json:"updated_on"`
+	User         Identifier   `json:"user"`
+	Project      Identifier   `json:"project"`
+	Activity     Identifier   `json:"activity"`
+	CustomFields []ValueField `json:"custom_fields,omitempty"`
+	Issue        struct {
 		Id int `json:"id"`
 	} `json:"issue"`
 }
 
+// PageMeta describes the page of results returned by a list request.
+type PageMeta struct {
+	TotalCount int `json:"total_count"`
+	Offset     int `json:"offset"`
+	Limit      int `json:"limit"`
+}
+
+// APIError is returned when Redmine responds to a request with an error
+// status. Path is the path of the request, without its query string. Messages
+// holds any error messages included in the response, such as validation
+// errors.
+type APIError struct {
+	StatusCode int
+	Status     string
+	Path       string
+	Messages   []string
+}
+
+func (err *APIError) Error() string {
+	message := err.Status
+	if err.Path != "" {
+		message = err.Path + ": " + message
+	}
+	if len(err.Messages) > 0 {
+		message += ": " + strings.Join(err.Messages, "; ")
+	}
+	return message
+}
+
+// Unwrap returns the sentinel error corresponding to an APIError's status, if
+// there is one, so that errors.Is(err, ErrNotFound) can be used to check for a
+// missing resource, errors.Is(err, ErrUnauthorized) for invalid credentials,
+// and errors.Is(err, ErrForbidden) for a lack of permission.
+func (err *APIError) Unwrap() error {
+	switch err.StatusCode {
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusForbidden:
+		return ErrForbidden
+	}
+	return nil
+}
+
+// DryRunError is returned in place of sending a request that would modify
+// data in Redmine when a Session is in dry run mode. It holds the request that
+// would have been sent.
+type DryRunError struct {
+	Method string
+	Url    string
+	Body   []byte
+}
+
+func (err *DryRunError) Error() string {
+	return fmt.Sprintf("dry run: %s %s: %s", err.Method, err.Url, string(err.Body))
+}
+
+// NewTimeEntry is used to create time entries in Redmine. Time is logged
+// against Issue if it's set, and otherwise against Project.
+type NewTimeEntry struct {
+	Issue        int          `json:"issue_id,omitempty"`
+	Project      int          `json:"project_id,omitempty"`
+	SpentOn      string       `json:"spent_on,omitempty"`
+	Hours        float64      `json:"hours"`
+	Activity     int          `json:"activity_id,omitempty"`
+	Comments     string       `json:"comments,omitempty"`
+	CustomFields []ValueField `json:"custom_fields,omitempty"`
+}
+
 // An Identifier is a name/id pair.
 type Identifier struct {
 	Name string `json:"name,omitempty"`
 	Id   int    `json:"id,omitempty"`
 }
 
-// A ValueField is an Identifier with an associated value.
+// A ValueField is an Identifier with an associated value. Redmine sends the
+// value of a custom field that allows multiple values as a list, which is
+// stored in Values rather than Value.
 type ValueField struct {
 	Identifier
-	Value string `json:"value,omitempty"`
+	Value  string
+	Values []string
+}
+
+// UnmarshalJSON decodes a ValueField whose value is either a string or a list
+// of strings.
+func (field *ValueField) UnmarshalJSON(data []byte) error {
+	var f struct {
+		Identifier
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+
+	*field = ValueField{Identifier: f.Identifier}
+	value := bytes.TrimSpace(f.Value)
+	if len(value) == 0 {
+		return nil
+	}
+	if value[0] == '[' {
+		return json.Unmarshal(value, &field.Values)
+	}
+	return json.Unmarshal(value, &field.Value)
+}
+
+// MarshalJSON encodes a ValueField, sending Values as a list if it's set and
+// Value otherwise.
+func (field ValueField) MarshalJSON() ([]byte, error) {
+	f := struct {
+		Identifier
+		Value interface{} `json:"value,omitempty"`
+	}{Identifier: field.Identifier}
+	if field.Values != nil {
+		f.Value = field.Values
+	} else if field.Value != "" {
+		f.Value = field.Value
+	}
+	return json.Marshal(f)
+}
+
+func newSessionCache() *sessionCache {
+	return &sessionCache{
+		users:    map[int]User{},
+		logins:   map[string]int{},
+		projects: map[int]Project{},
+	}
 }
 
 // NewSession creates a new session for a Redmine server.
@@ -133,6 +530,7 @@ func NewSession(redmineUrl, username, password string) (Session, error) {
 		url:      redmineUrl,
 		username: username,
 		password: password,
+		cache:    newSessionCache(),
 	}
 
 	user, err := session.GetUser()
@@ -140,7 +538,7 @@ func NewSession(redmineUrl, username, password string) (Session, error) {
 		return session, err
 	}
 
-	log.Printf("got user: %s", user)
+	log.Printf("got user %s (%d)", user.Login, user.Id)
 	session.apiKey = user.ApiKey
 
 	return session, nil
@@ -151,6 +549,7 @@ func OpenSession(redmineUrl, apiKey string) Session {
 	session := Session{
 		url:    redmineUrl,
 		apiKey: apiKey,
+		cache:  newSessionCache(),
 	}
 	return session
 }
@@ -165,11 +564,235 @@ func (session *Session) ApiKey() string {
 	return session.apiKey
 }
 
+// WithBaseContext returns a copy of a Session that makes all of its requests
+// with ctx. A deadline set on ctx limits the total time of each operation the
+// copy performs, including operations that make many requests, such as
+// GetIssuesWithFilter; a client timeout only limits each request. The copy
+// shares the original Session's cached data.
+func (session *Session) WithBaseContext(ctx context.Context) *Session {
+	scoped := *session
+	scoped.ctx = ctx
+	return &scoped
+}
+
+// SetHttpClient sets the HTTP client a Session uses to communicate with
+// Redmine. By default, all sessions share a single client.
+func (session *Session) SetHttpClient(httpClient *http.Client) {
+	session.client = httpClient
+}
+
+// SetInsecureSkipVerify controls whether a Session verifies the Redmine
+// server's TLS certificate, which may be necessary for servers with
+// self-signed certificates. It gives the Session its own client with a copy
+// of the current transport, or of http.DefaultTransport if the client has no
+// transport. It returns an error, leaving the Session unchanged, if that
+// transport isn't an *http.Transport, such as one set with SetTransport.
+func (session *Session) SetInsecureSkipVerify(skip bool) error {
+	current := session.httpClient()
+
+	base := current.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	transport, ok := base.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("unable to configure TLS for a transport of type %T", base)
+	}
+	transport = transport.Clone()
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+	transport.TLSClientConfig.InsecureSkipVerify = skip
+
+	httpClient := *current
+	httpClient.Transport = transport
+	session.client = &httpClient
+	return nil
+}
+
+// SetTimeout limits the time each request a Session makes may take, including
+// reading the response body. It gives the Session its own copy of its current
+// client with the timeout set. A deadline on the Session's base context, set
+// with WithBaseContext, applies as well, so whichever ends sooner cancels a
+// request; unlike the timeout, the deadline limits the total time of
+// operations that make many requests. A timeout of 0 means no timeout.
+func (session *Session) SetTimeout(timeout time.Duration) {
+	httpClient := *session.httpClient()
+	httpClient.Timeout = timeout
+	session.client = &httpClient
+}
+
+// DefaultProject sets the project used by issue and time entry lists, and by
+// CreateIssue, when a project isn't explicitly given. Lists filtered by
+// "issue_id" or "parent_id" aren't limited to the default project, since the
+// issues they name may be in other projects. An id of 0 clears the default
+// project.
+func (session *Session) DefaultProject(id int) {
+	session.defaultProject = id
+}
+
+// SetValidateIssues controls whether CreateIssue checks that an issue's
+// tracker and category are available in its project and that its required
+// custom fields are set before creating it, and whether TransitionIssue
+// checks that the Session user may change an issue to the new status. The
+// checks cost extra requests.
+func (session *Session) SetValidateIssues(validate bool) {
+	session.validate = validate
+}
+
+// SetConcurrency sets the number of requests that methods operating on many
+// items at once, such as CreateTimeEntries, may make at the same time. The
+// default is 1, meaning requests are made one after another.
+func (session *Session) SetConcurrency(concurrency int) {
+	session.concurrency = concurrency
+}
+
+// SetMaxPages limits the number of pages of results methods that return whole
+// lists, such as GetIssuesWithFilter, will request. A limit of 1 disables
+// pagination, so only the first page of results is returned. The default, 0,
+// means all pages are requested. Methods that would give wrong results from
+// part of a list always request every page: those that look items up in a
+// list, such as FindProjectByName, those that total a list, such as
+// GetIssueTimeSummary, and those that update every item in a list, such as
+// ReassignIssues.
+func (session *Session) SetMaxPages(maxPages int) {
+	session.maxPages = maxPages
+}
+
+// SetPageSize sets the number of items requested per page by methods that
+// page through lists, unless their filter sets "limit". Smaller pages return
+// sooner, which suits methods that handle items as they arrive, such as
+// EachIssue and ExportIssuesCSV. The size is clamped to 1..100; the default
+// is 100.
+func (session *Session) SetPageSize(pageSize int) {
+	session.pageSize = pageSize
+}
+
+// SetMaxResponseBytes limits the size of the response bodies a Session reads,
+// so that a misbehaving server can't exhaust memory. A larger response causes
+// an error. The default is 16 MiB; a negative limit means no limit.
+// Attachments downloaded with DownloadAttachment aren't limited.
+func (session *Session) SetMaxResponseBytes(limit int64) {
+	session.maxResponseBytes = limit
+}
+
+// SetRequestHook sets a function that's called with every request a Session
+// makes just before it's sent, such as for logging or metrics.
+func (session *Session) SetRequestHook(hook func(*http.Request)) {
+	session.onRequest = hook
+}
+
+// SetResponseHook sets a function that's called with every response a Session
+// receives along with the time the request took. The hook gets a copy of the
+// response body, so reading it doesn't affect the Session.
+func (session *Session) SetResponseHook(hook func(*http.Response, time.Duration)) {
+	session.onResponse = hook
+}
+
+// SetTransport sets the transport used to send a Session's requests, giving
+// the Session its own copy of its current client. This is how tracing is
+// added to a Session: for example, with OpenTelemetry,
+//
+//	session.SetTransport(otelhttp.NewTransport(http.DefaultTransport,
+//		otelhttp.WithSpanNameFormatter(func(_ string, req *http.Request) string {
+//			return redmine.SpanName(req)
+//		})))
+//
+// names spans after templated request paths, such as "GET /issues/{id}.json",
+// rather than after the many distinct URLs a Session requests.
+func (session *Session) SetTransport(transport http.RoundTripper) {
+	httpClient := *session.httpClient()
+	httpClient.Transport = transport
+	session.client = &httpClient
+}
+
+// A SpanStarter starts a tracing span with the given name for a request. It
+// returns the context to send the request with, and a function that ends the
+// span once the request's response or error is known.
+type SpanStarter func(ctx context.Context, name string) (context.Context, func(*http.Response, error))
+
+// WithTracingTransport returns a transport, for use with SetTransport, that
+// sends each request with base inside a span started by start and named by
+// SpanName. If base is nil, http.DefaultTransport is used. For example, with
+// OpenTelemetry,
+//
+//	session.SetTransport(redmine.WithTracingTransport(nil,
+//		func(ctx context.Context, name string) (context.Context, func(*http.Response, error)) {
+//			ctx, span := tracer.Start(ctx, name)
+//			return ctx, func(*http.Response, error) { span.End() }
+//		}))
+func WithTracingTransport(base http.RoundTripper, start SpanStarter) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &tracingTransport{base: base, start: start}
+}
+
+// tracingTransport is the transport returned by WithTracingTransport.
+type tracingTransport struct {
+	base  http.RoundTripper
+	start SpanStarter
+}
+
+func (transport *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, end := transport.start(req.Context(), SpanName(req))
+	resp, err := transport.base.RoundTrip(req.WithContext(ctx))
+	end(resp, err)
+	return resp, err
+}
+
+// SetAuthMode sets how a Session sends its API key to Redmine.
+func (session *Session) SetAuthMode(mode AuthMode) {
+	session.authMode = mode
+}
+
+// SetUserAgent sets the User-Agent header a Session sends, which identifies
+// the application in Redmine's access logs.
+func (session *Session) SetUserAgent(userAgent string) {
+	session.userAgent = userAgent
+}
+
+// SetHeader sets a header that will be sent with every request the Session
+// makes, such as one required by an authenticating proxy. Headers set this way
+// never replace the API key header.
+func (session *Session) SetHeader(key, value string) {
+	if session.headers == nil {
+		session.headers = http.Header{}
+	}
+	session.headers.Set(key, value)
+}
+
+// Impersonate makes subsequent requests act on behalf of the user with the
+// given login. This requires the Session user to be an administrator.
+func (session *Session) Impersonate(login string) {
+	session.switchUser = login
+}
+
+// StopImpersonating makes subsequent requests act as the Session user again.
+func (session *Session) StopImpersonating() {
+	session.switchUser = ""
+}
+
+// SetDryRun enables or disables dry run mode. In dry run mode, requests that
+// would modify data aren't sent; the methods making them return a *DryRunError
+// describing the request instead.
+func (session *Session) SetDryRun(dryRun bool) {
+	session.dryRun = dryRun
+}
+
 // IssueUrl returns the REST url for a particular issue.
 func (session *Session) IssueUrl(issue Issue) string {
 	return fmt.Sprintf("%s/issues/%d", session.url, issue.Id)
 }
 
+// Ping checks that Redmine can be reached and accepts the Session's
+// credentials. It returns an error wrapping ErrUnauthorized if the
+// credentials are rejected.
+func (session *Session) Ping(ctx context.Context) error {
+	_, err := session.WithBaseContext(ctx).get("/users/current.json", nil)
+	return err
+}
+
 // GetUser returns account data for the user a Session was created for.
 func (session *Session) GetUser() (user User, err error) {
 	var data []byte
@@ -189,193 +812,1612 @@ func (session *Session) GetUser() (user User, err error) {
 	return
 }
 
-// GetIssues returns an array of all open issues assigned to the Session user.
-func (session *Session) GetIssues() ([]Issue, error) {
-	params := map[string]string{
-		// "assigned_to_id": "me",
-		"watcher_id": "me",
-		"limit":      "100"}
-	var issues []Issue
-	offset := 0
-
-	for {
-		data, err := session.get("/issues.json", params)
-		if err != nil {
-			return nil, err
-		}
-
-		var list struct {
-			Issues     []Issue `json:"issues"`
-			Limit      int     `json:"limit"`
-			Offset     int     `json:"offset"`
-			TotalCount int     `json:"total_count"`
-		}
-
-		dec := json.NewDecoder(bytes.NewReader(data))
-		err = dec.Decode(&list)
-		if err != nil {
-			return nil, err
-		}
+// CurrentUserId returns the id of the Session user, which is the impersonated
+// user if there is one. The id is only looked up in Redmine the first time
+// it's needed for each set of credentials and impersonated user.
+func (session *Session) CurrentUserId() (int, error) {
+	auth := session.apiKey + ":" + session.username
+	if session.switchUser != "" {
+		auth += " " + session.switchUser
+	}
 
-		issues = append(issues, list.Issues...)
-		if len(issues) == list.TotalCount {
-			break
-		}
+	session.cache.Lock()
+	id, idAuth := session.cache.currentUserId, session.cache.currentUserAuth
+	session.cache.Unlock()
+	if id != 0 && idAuth == auth {
+		return id, nil
+	}
 
-		offset += len(issues)
-		params["offset"] = strconv.Itoa(offset)
+	user, err := session.GetUser()
+	if err != nil {
+		return 0, err
 	}
 
-	return issues, nil
+	session.cache.Lock()
+	session.cache.currentUserId = user.Id
+	session.cache.currentUserAuth = auth
+	session.cache.Unlock()
+	return user.Id, nil
 }
 
-// GetIssue returns a specific issue.
-func (session *Session) GetIssue(id int) (issue Issue, err error) {
+// GetUserById returns account data for a specific user along with the
+// associated data named by includes, such as "memberships" or "groups". The
+// user's API key is only returned to administrators and to the user
+// themselves.
+func (session *Session) GetUserById(id int, includes ...string) (user User, err error) {
+	var params map[string]string
+	if len(includes) > 0 {
+		params = map[string]string{"include": strings.Join(includes, ",")}
+	}
+
 	var data []byte
-	if data, err = session.get("/issues/"+strconv.Itoa(id)+".json", nil); err != nil {
+	if data, err = session.get("/users/"+strconv.Itoa(id)+".json", params); err != nil {
 		return
 	}
 
-	var i struct {
-		Issue Issue `json:"issue"`
+	var u struct {
+		User User `json:"user"`
 	}
 	dec := json.NewDecoder(bytes.NewReader(data))
-	if err = dec.Decode(&i); err != nil {
+	if err = dec.Decode(&u); err != nil {
 		return
 	}
-	issue = i.Issue
+
+	user = u.User
 	return
 }
 
-func (session *Session) UpdateIssue(id int, issue UpdateIssue) (err error) {
-	log.Printf("Updating issue %v", issue)
-	data := map[string]interface{}{
-		"issue": issue,
+// RegenerateApiKey would reset a user's API key. Redmine only supports this
+// through its web interface, so it always returns ErrUnsupported; the current
+// key can be read with GetUserById.
+func (session *Session) RegenerateApiKey(userId int) (string, error) {
+	return "", ErrUnsupported
+}
+
+// GetUsers returns an array of the users matching the given filter
+// parameters. The "name" parameter matches users by login, first name, last
+// name, or mail. Listing users requires administrator privileges.
+func (session *Session) GetUsers(filter map[string]string) ([]User, error) {
+	var users []User
+	if err := session.getAll("/users.json", filter, "users", &users); err != nil {
+		return nil, err
 	}
-	var resp []byte
-	resp, err = session.put("/issues/"+strconv.Itoa(id)+".json", data)
-	log.Printf("got response: %s", string(resp))
-	return err
+	return users, nil
 }
 
-// GetTimeEntries returns all time entries from a given number of days in the
-// past until now.
-func (session *Session) GetTimeEntries(daysBack int) ([]TimeEntry, error) {
-	since := time.Now().AddDate(0, 0, -daysBack).Format("2006-01-02")
-	until := time.Now().Format("2006-01-02")
-	params := map[string]string{
-		"user_id":  "me",
-		"spent_on": "><" + since + "|" + until,
-		"limit":    "100"}
+// GetIssues returns an array of all open issues watched by the Session user.
+// It's the same as GetWatchedIssues.
+func (session *Session) GetIssues() ([]Issue, error) {
+	return session.GetWatchedIssues()
+}
 
-	var entries []TimeEntry
-	offset := 0
+// GetAssignedIssues returns an array of all open issues assigned to the
+// Session user.
+func (session *Session) GetAssignedIssues() ([]Issue, error) {
+	return session.GetIssuesWithFilter(map[string]string{"assigned_to_id": "me"})
+}
 
-	for {
-		data, err := session.get("/time_entries.json", params)
-		if err != nil {
-			return nil, err
-		}
+// GetReportedIssues returns an array of all open issues created by the Session
+// user.
+func (session *Session) GetReportedIssues() ([]Issue, error) {
+	return session.GetIssuesWithFilter(map[string]string{"author_id": "me"})
+}
+
+// GetWatchedIssues returns an array of all open issues watched by the Session
+// user.
+func (session *Session) GetWatchedIssues() ([]Issue, error) {
+	return session.GetIssuesWithFilter(map[string]string{"watcher_id": "me"})
+}
 
-		var list struct {
-			TimeEntries []TimeEntry `json:"time_entries"`
-			Limit       int         `json:"limit"`
-			Offset      int         `json:"offset"`
-			TotalCount  int         `json:"total_count"`
+// WatchedIssueIds returns the ids of the issues among issues that the Session
+// user watches. Unlike retrieving each issue with the "watchers" include, which
+// costs a request per issue, it requests the watched issues among them a
+// hundred at a time.
+func (session *Session) WatchedIssueIds(issues []Issue) (map[int]bool, error) {
+	watched := map[int]bool{}
+	for start := 0; start < len(issues); start += maxPageSize {
+		end := start + maxPageSize
+		if end > len(issues) {
+			end = len(issues)
+		}
+		ids := make([]int, end-start)
+		for i := range ids {
+			ids[i] = issues[start+i].Id
 		}
 
-		dec := json.NewDecoder(bytes.NewReader(data))
-		err = dec.Decode(&list)
-		if err != nil {
+		// A chunk has at most a page of matches, so it takes one request
+		// whatever the Session's page size and page limit are
+		params := ApplyFilters(map[string]string{
+			"watcher_id": "me",
+			"status_id":  StatusAll,
+			"limit":      strconv.Itoa(maxPageSize),
+		}, IssueIdIn(ids...))
+		var matches []Issue
+		if _, err := session.getPage("/issues.json", params, "issues", &matches); err != nil {
 			return nil, err
 		}
-
-		entries = append(entries, list.TimeEntries...)
-		if len(entries) == list.TotalCount {
-			break
+		for _, issue := range matches {
+			watched[issue.Id] = true
 		}
+	}
+	return watched, nil
+}
 
-		offset += len(entries)
-		params["offset"] = strconv.Itoa(offset)
+// GetAllIssues returns an array of all the issues matching the given filter
+// parameters, whether they're open or closed.
+func (session *Session) GetAllIssues(filter map[string]string) ([]Issue, error) {
+	params := map[string]string{}
+	for key, value := range filter {
+		params[key] = value
 	}
+	params["status_id"] = StatusAll
+	return session.GetIssuesWithFilter(params)
+}
 
-	return entries, nil
+// GetIssuesWithFilter returns an array of all the issues matching the given
+// filter parameters, such as "project_id", "status_id", or "cf_<id>" for a
+// custom field. Parameters are passed to Redmine as is; see ApplyFilters for
+// help building them.
+func (session *Session) GetIssuesWithFilter(filter map[string]string) ([]Issue, error) {
+	var issues []Issue
+	if err := session.getAll("/issues.json", session.projectFilter(filter), "issues", &issues); err != nil {
+		return nil, err
+	}
+	return issues, nil
 }
 
-// GetProjects returns an array of all the projects the Session user belongs to.
-func (session *Session) GetProjects() ([]Project, error) {
-	params := map[string]string{
-		"limit": "100"}
+// GetIssuesPage returns a single page of the issues matching the given filter
+// parameters. The "offset" and "limit" parameters select the page.
+func (session *Session) GetIssuesPage(filter map[string]string) (issues []Issue, meta PageMeta, err error) {
+	meta, err = session.getPage("/issues.json", session.projectFilter(filter), "issues", &issues)
+	return
+}
 
-	var projects []Project
-	offset := 0
+// EachIssue calls fn for each of the issues matching the given filter
+// parameters, requesting them a page at a time. It stops at the first error fn
+// returns and returns that error.
+func (session *Session) EachIssue(filter map[string]string, fn func(Issue) error) error {
+	params := session.listParams(filter)
+	offset, _ := strconv.Atoi(params["offset"])
 
-	for {
-		data, err := session.get("/projects.json", params)
+	for pages := 1; ; pages++ {
+		params["offset"] = strconv.Itoa(offset)
+		issues, meta, err := session.GetIssuesPage(params)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		var list struct {
-			Projects   []Project `json:"projects"`
-			TotalCount int       `json:"total_count"`
-			Offset     int       `json:"offset"`
-			Limit      int       `json:"limit"`
+		for _, issue := range issues {
+			if err = fn(issue); err != nil {
+				return err
+			}
 		}
 
-		dec := json.NewDecoder(bytes.NewReader(data))
-		err = dec.Decode(&list)
-		if err != nil {
-			return nil, err
+		offset += len(issues)
+		if len(issues) == 0 || offset >= meta.TotalCount || session.lastPage(pages) {
+			return nil
 		}
+	}
+}
 
-		projects = append(projects, list.Projects...)
-		if len(projects) == list.TotalCount {
-			break
-		}
+// CountIssues returns the number of issues matching the given filter
+// parameters without retrieving them all.
+func (session *Session) CountIssues(filter map[string]string) (int, error) {
+	params := map[string]string{}
+	for key, value := range filter {
+		params[key] = value
+	}
+	params["limit"] = "1"
+	delete(params, "offset")
 
-		offset = len(projects)
-		params["offset"] = strconv.Itoa(offset)
+	_, meta, err := session.GetIssuesPage(params)
+	if err != nil {
+		return 0, err
 	}
+	return meta.TotalCount, nil
+}
 
-	return projects, nil
+// GetIssue returns a specific issue.
+func (session *Session) GetIssue(id int) (issue Issue, err error) {
+	return session.GetIssueWithIncludes(id)
 }
 
-// GetIssueStatuses returns an array of all the available issue statuses.
-func (session *Session) GetIssueStatuses() ([]IssueStatus, error) {
-	data, err := session.get("/issue_statuses.json", nil)
-	if err != nil {
-		return nil, err
+// GetIssueWithIncludes returns a specific issue along with the associated data
+// named by includes, such as "watchers". Redmine silently omits associated
+// data the Session user isn't allowed to see, so the corresponding Issue
+// fields are left empty rather than causing an error.
+func (session *Session) GetIssueWithIncludes(id int, includes ...string) (issue Issue, err error) {
+	var params map[string]string
+	if len(includes) > 0 {
+		params = map[string]string{"include": strings.Join(includes, ",")}
 	}
 
-	var statuses struct {
-		IssueStatuses []IssueStatus `json:"issue_statuses"`
+	var data []byte
+	if data, err = session.get("/issues/"+strconv.Itoa(id)+".json", params); err != nil {
+		return
 	}
 
+	var i struct {
+		Issue Issue `json:"issue"`
+	}
 	dec := json.NewDecoder(bytes.NewReader(data))
-	err = dec.Decode(&statuses)
-	if err != nil {
-		return nil, err
+	if err = dec.Decode(&i); err != nil {
+		return
 	}
-
-	return statuses.IssueStatuses, nil
+	issue = i.Issue
+	return
 }
 
-// support /////////////////////////////////////////////////////////////
-
-func toQueryString(params map[string]string) string {
-	values := url.Values{}
-	for key, value := range params {
-		values.Set(key, value)
+// GetIssueRaw returns a specific issue as the JSON object Redmine returned,
+// for decoding fields Issue doesn't have into other types.
+func (session *Session) GetIssueRaw(id int) (issue json.RawMessage, err error) {
+	var data []byte
+	if data, err = session.get("/issues/"+strconv.Itoa(id)+".json", nil); err != nil {
+		return
+	}
+
+	var i struct {
+		Issue json.RawMessage `json:"issue"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err = dec.Decode(&i); err != nil {
+		return
+	}
+	issue = i.Issue
+	return
+}
+
+// GetIssueJournals returns the history of an issue, oldest first.
+func (session *Session) GetIssueJournals(id int) ([]Journal, error) {
+	issue, err := session.GetIssueWithIncludes(id, "journals")
+	if err != nil {
+		return nil, err
+	}
+	return issue.Journals, nil
+}
+
+// IssueExists returns true if an issue exists and is visible to the Session
+// user, and false if it doesn't. Other failures are returned as errors.
+func (session *Session) IssueExists(id int) (bool, error) {
+	_, err := session.request("HEAD", session.url+"/issues/"+strconv.Itoa(id)+".json", nil)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// WaitForStatus polls an issue every interval until it has the given status.
+// If ctx is done first, it returns ctx's error. The interval must be positive.
+func (session *Session) WaitForStatus(ctx context.Context, issueId, statusId int, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("polling interval %v is not positive", interval)
+	}
+
+	scoped := session.WithBaseContext(ctx)
+	for {
+		issue, err := scoped.GetIssue(issueId)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return err
+		}
+		if issue.Status.Id == statusId {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// CreateIssue creates a new issue and returns it. If issue doesn't specify a
+// project, the Session's default project is used.
+//
+// CreateIssue never retries a request, so an issue is created at most once.
+// However, if the request fails after reaching Redmine, such as by timing out,
+// the issue may have been created anyway; CreateIssueOnce can be retried
+// safely in that case.
+func (session *Session) CreateIssue(issue UpdateIssue) (created Issue, err error) {
+	if issue.Project == 0 {
+		issue.Project = session.defaultProject
+	}
+
+	if session.validate {
+		if err = session.validateIssue(issue); err != nil {
+			return
+		}
+	}
+
+	err = session.create("/issues.json", "issue", issue, &created)
+	return
+}
+
+// CreateIssueOnce creates a new issue identified by key and returns it, or
+// returns the issue that was already created with key. The key is stored in
+// the custom field with id fieldId, which must be an issue custom field
+// enabled for the issue's project and usable as a filter.
+//
+// Since an earlier attempt is found before creating an issue, CreateIssueOnce
+// can be retried until it succeeds without creating duplicate issues, as long
+// as the same key is used for every attempt. Attempts made concurrently with
+// the same key may still create duplicates.
+func (session *Session) CreateIssueOnce(issue UpdateIssue, fieldId int, key string) (Issue, error) {
+	existing, err := session.FindIssueByIdempotencyKey(fieldId, key)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return existing, err
+	}
+
+	issue.CustomFields = append(issue.CustomFields, ValueField{
+		Identifier: Identifier{Id: fieldId},
+		Value:      key,
+	})
+	return session.CreateIssue(issue)
+}
+
+// FindIssueByIdempotencyKey returns the issue created by CreateIssueOnce with
+// the given custom field id and key, whether it's open or closed and in
+// whichever project. It returns ErrNotFound if there isn't one.
+func (session *Session) FindIssueByIdempotencyKey(fieldId int, key string) (Issue, error) {
+	filter := ApplyFilters(map[string]string{"status_id": StatusAll, "limit": "1"},
+		WithCustomFieldFilter(fieldId, key))
+
+	// The key is unique across projects, so the default project doesn't apply
+	var issues []Issue
+	_, err := session.getPage("/issues.json", filter, "issues", &issues)
+	if err != nil {
+		return Issue{}, err
+	}
+	if len(issues) == 0 {
+		return Issue{}, ErrNotFound
+	}
+	return issues[0], nil
+}
+
+func (session *Session) UpdateIssue(id int, issue UpdateIssue) (err error) {
+	log.Printf("Updating issue %v", issue)
+	data := map[string]interface{}{
+		"issue": issue,
+	}
+	var resp []byte
+	resp, err = session.put("/issues/"+strconv.Itoa(id)+".json", data)
+	log.Printf("got response: %s", string(resp))
+	return err
+}
+
+// CloseIssue sets an issue's status to the first closed status configured in
+// Redmine, adding note to the issue's history if it isn't empty.
+func (session *Session) CloseIssue(id int, note string) error {
+	statuses, err := session.cachedIssueStatuses()
+	if err != nil {
+		return err
+	}
+
+	for _, status := range statuses {
+		if status.IsClosed {
+			return session.UpdateIssue(id, UpdateIssue{Status: status.Id, Notes: note})
+		}
+	}
+
+	return fmt.Errorf("no closed issue status is configured")
+}
+
+// ReopenIssue sets an issue's status to the default open status configured in
+// Redmine, or to the first open status if there's no default, adding note to
+// the issue's history if it isn't empty.
+func (session *Session) ReopenIssue(id int, note string) error {
+	statuses, err := session.cachedIssueStatuses()
+	if err != nil {
+		return err
+	}
+
+	statusId := 0
+	for _, status := range statuses {
+		if status.IsClosed {
+			continue
+		}
+		if status.IsDefault {
+			statusId = status.Id
+			break
+		}
+		if statusId == 0 {
+			statusId = status.Id
+		}
+	}
+
+	if statusId == 0 {
+		return fmt.Errorf("no open issue status is configured")
+	}
+	return session.UpdateIssue(id, UpdateIssue{Status: statusId, Notes: note})
+}
+
+// MoveOptions describes how to change an issue's attributes when moving it to
+// another project. The tracker, category, and version of the issue must exist
+// in the target project for the move to succeed.
+type MoveOptions struct {
+	// Tracker, Category, and FixedVersion, if not 0, replace the issue's
+	// current values.
+	Tracker      int
+	Category     int
+	FixedVersion int
+
+	// ClearCategory and ClearFixedVersion remove the issue's category and
+	// version.
+	ClearCategory     bool
+	ClearFixedVersion bool
+}
+
+// MoveIssue moves an issue to another project.
+func (session *Session) MoveIssue(id, targetProjectId int, opts MoveOptions) error {
+	update := UpdateIssue{
+		Project:      targetProjectId,
+		Tracker:      opts.Tracker,
+		Category:     opts.Category,
+		FixedVersion: opts.FixedVersion,
+	}
+	if opts.ClearCategory {
+		update.Category = 0
+		update.ForceSend = append(update.ForceSend, "category_id")
+	}
+	if opts.ClearFixedVersion {
+		update.FixedVersion = 0
+		update.ForceSend = append(update.ForceSend, "fixed_version_id")
+	}
+
+	if err := session.UpdateIssue(id, update); err != nil {
+		return fmt.Errorf("unable to move issue %d to project %d: %w", id, targetProjectId, err)
+	}
+	return nil
+}
+
+// TransitionIssue changes the status of an issue, adding note to the issue's
+// history if it isn't empty. If the Session validates issues, the new status
+// is first checked against the statuses Redmine allows the Session user to
+// change the issue to; servers older than Redmine 5.0 don't provide these, so
+// the check is skipped for them.
+func (session *Session) TransitionIssue(id int, toStatusId int, note string) error {
+	if session.validate {
+		issue, err := session.GetIssueWithIncludes(id, "allowed_statuses")
+		if err != nil {
+			return err
+		}
+
+		if issue.AllowedStatuses != nil {
+			ok := false
+			names := make([]string, len(issue.AllowedStatuses))
+			for i, status := range issue.AllowedStatuses {
+				ok = ok || status.Id == toStatusId
+				names[i] = fmt.Sprintf("%s (%d)", status.Name, status.Id)
+			}
+			if !ok {
+				return fmt.Errorf("issue %d can't be changed to status %d, allowed statuses are %s",
+					id, toStatusId, strings.Join(names, ", "))
+			}
+		}
+	}
+
+	err := session.UpdateIssue(id, UpdateIssue{Status: toStatusId, Notes: note})
+	if err != nil {
+		return fmt.Errorf("unable to change status of issue %d: %w", id, err)
+	}
+	return nil
+}
+
+// AssignIssueByLogin assigns an issue to the user with the given login.
+func (session *Session) AssignIssueByLogin(issueId int, login string) error {
+	userId, err := session.userIdForLogin(login)
+	if err != nil {
+		return err
+	}
+	return session.UpdateIssue(issueId, UpdateIssue{AssignedTo: userId})
+}
+
+// AddWatcher makes a user a watcher of an issue.
+func (session *Session) AddWatcher(issueId, userId int) error {
+	data := map[string]interface{}{
+		"user_id": userId,
+	}
+	_, err := session.post("/issues/"+strconv.Itoa(issueId)+"/watchers.json", data)
+	return err
+}
+
+// RemoveWatcher stops a user watching an issue.
+func (session *Session) RemoveWatcher(issueId, userId int) error {
+	_, err := session.delete("/issues/" + strconv.Itoa(issueId) + "/watchers/" + strconv.Itoa(userId) + ".json")
+	return err
+}
+
+// AddWatcherToIssues makes a user a watcher of several issues, making up to
+// the Session's concurrency limit of requests at once. It returns the result
+// for each issue, keyed by issue id, along with an error joining the errors
+// for any issues the user couldn't be added to.
+func (session *Session) AddWatcherToIssues(issueIds []int, userId int) (map[int]error, error) {
+	errs := make([]error, len(issueIds))
+	session.forEach(len(issueIds), func(i int) {
+		errs[i] = session.AddWatcher(issueIds[i], userId)
+	})
+
+	results := map[int]error{}
+	var failed []error
+	for i, err := range errs {
+		results[issueIds[i]] = err
+		if err != nil {
+			failed = append(failed, fmt.Errorf("issue %d: %w", issueIds[i], err))
+		}
+	}
+	return results, errors.Join(failed...)
+}
+
+// ReassignIssues assigns the open issues assigned to one user, and matching
+// the given filter parameters, to another user, making up to the Session's
+// concurrency limit of requests at once. It returns the number of issues
+// reassigned, along with an error joining the errors for any issues that
+// couldn't be reassigned.
+func (session *Session) ReassignIssues(fromUserId, toUserId int, filter map[string]string) (int, error) {
+	params := map[string]string{}
+	for key, value := range filter {
+		params[key] = value
+	}
+	params["assigned_to_id"] = strconv.Itoa(fromUserId)
+	params["status_id"] = StatusOpen
+
+	issues, err := session.unpaged().GetIssuesWithFilter(params)
+	if err != nil {
+		return 0, err
+	}
+
+	errs := make([]error, len(issues))
+	session.forEach(len(issues), func(i int) {
+		if err := session.UpdateIssue(issues[i].Id, UpdateIssue{AssignedTo: toUserId}); err != nil {
+			errs[i] = fmt.Errorf("issue %d: %w", issues[i].Id, err)
+		}
+	})
+
+	reassigned := 0
+	for _, err := range errs {
+		if err == nil {
+			reassigned++
+		}
+	}
+	return reassigned, errors.Join(errs...)
+}
+
+// SetDoneRatio sets an issue's done ratio, which must be a percentage from 0
+// to 100.
+func (session *Session) SetDoneRatio(issueId, ratio int) error {
+	if ratio < 0 || ratio > 100 {
+		return fmt.Errorf("invalid done ratio %d, must be from 0 to 100", ratio)
+	}
+	return session.UpdateIssue(issueId, UpdateIssue{DoneRatio: ratio, ForceSend: []string{"done_ratio"}})
+}
+
+// SetIssueCategoryByName sets an issue's category to the category with the
+// given name in the issue's project.
+func (session *Session) SetIssueCategoryByName(issueId int, categoryName string) error {
+	issue, err := session.GetIssue(issueId)
+	if err != nil {
+		return err
+	}
+	project, err := session.cachedProject(issue.Project.Id)
+	if err != nil {
+		return err
+	}
+
+	for _, category := range project.IssueCategories {
+		if category.Name == categoryName {
+			return session.UpdateIssue(issueId, UpdateIssue{Category: category.Id})
+		}
+	}
+	return fmt.Errorf("category %s does not exist in project %s, available categories are %s",
+		categoryName, project.Name, identifierList(project.IssueCategories))
+}
+
+// GetTimeEntries returns all time entries from a given number of days in the
+// past until now.
+func (session *Session) GetTimeEntries(daysBack int) ([]TimeEntry, error) {
+	now := time.Now()
+	return session.TimeEntriesBetween(now.AddDate(0, 0, -daysBack), now,
+		map[string]string{"user_id": "me"})
+}
+
+// TimeEntriesBetween returns all time entries matching the given filter
+// parameters that were spent between two dates, inclusive. Only the calendar
+// dates of from and to, in their own locations, are used.
+func (session *Session) TimeEntriesBetween(from, to time.Time, filter map[string]string) ([]TimeEntry, error) {
+	params := map[string]string{}
+	for key, value := range filter {
+		params[key] = value
+	}
+	params["spent_on"] = dateRange(from, to)
+	return session.GetTimeEntriesWithFilter(params)
+}
+
+// GetTimeEntriesWithFilter returns all time entries matching the given filter
+// parameters, such as "issue_id" or "project_id".
+func (session *Session) GetTimeEntriesWithFilter(filter map[string]string) ([]TimeEntry, error) {
+	var entries []TimeEntry
+	if err := session.getAll("/time_entries.json", session.projectFilter(filter), "time_entries", &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// maxIssueDepth limits how deeply Descendants will descend into an issue tree.
+const maxIssueDepth = 100
+
+// Descendants returns all of an issue's subtasks, as returned in Children when
+// the issue is retrieved with the "children" include, in depth-first order.
+// Child issues only have their Id, Tracker, and Subject fields set. Issues that
+// appear more than once in the tree are only returned the first time.
+func (issue Issue) Descendants() []Issue {
+	var descendants []Issue
+	seen := map[int]bool{issue.Id: true}
+
+	var walk func(children []Issue, depth int)
+	walk = func(children []Issue, depth int) {
+		if depth > maxIssueDepth {
+			return
+		}
+		for _, child := range children {
+			if seen[child.Id] {
+				continue
+			}
+			seen[child.Id] = true
+			descendants = append(descendants, child)
+			walk(child.Children, depth+1)
+		}
+	}
+	walk(issue.Children, 1)
+
+	return descendants
+}
+
+// GetTimeEntriesPage returns a single page of the time entries matching the
+// given filter parameters. The "offset" and "limit" parameters select the
+// page.
+func (session *Session) GetTimeEntriesPage(filter map[string]string) (entries []TimeEntry, meta PageMeta, err error) {
+	meta, err = session.getPage("/time_entries.json", session.projectFilter(filter), "time_entries", &entries)
+	return
+}
+
+// CreateTimeEntry logs time in Redmine and returns the new time entry. SpentOn
+// must be a date in the form YYYY-MM-DD, or empty for the current date. If
+// entry specifies neither an issue nor a project, the Session's default
+// project is used.
+func (session *Session) CreateTimeEntry(entry NewTimeEntry) (timeEntry TimeEntry, err error) {
+	if entry.Issue == 0 && entry.Project == 0 {
+		entry.Project = session.defaultProject
+	}
+	if entry.Issue == 0 && entry.Project == 0 {
+		err = fmt.Errorf("time entry must have an issue or a project")
+		return
+	}
+	if entry.SpentOn != "" {
+		if _, err = time.Parse("2006-01-02", entry.SpentOn); err != nil {
+			err = fmt.Errorf("invalid spent on date %q, expected YYYY-MM-DD", entry.SpentOn)
+			return
+		}
+	}
+	if entry.Hours <= 0 {
+		err = fmt.Errorf("invalid hours %v, must be greater than 0", entry.Hours)
+		return
+	}
+
+	err = session.create("/time_entries.json", "time_entry", entry, &timeEntry)
+	return
+}
+
+// CreateTimeEntries creates several time entries, making up to the Session's
+// concurrency limit of requests at once. The returned time entries and errors
+// are in the same order as entries; for each entry, either the error is nil or
+// the time entry is empty.
+func (session *Session) CreateTimeEntries(entries []NewTimeEntry) ([]TimeEntry, []error) {
+	created := make([]TimeEntry, len(entries))
+	errs := make([]error, len(entries))
+
+	session.forEach(len(entries), func(i int) {
+		created[i], errs[i] = session.CreateTimeEntry(entries[i])
+	})
+
+	return created, errs
+}
+
+// GetIssueTimeSummary returns the estimated hours for an issue along with the
+// total hours spent on it. If includeSubtasks is true, the estimates and time
+// entries of all of the issue's descendants are included in the totals.
+func (session *Session) GetIssueTimeSummary(issueId int, includeSubtasks bool) (estimated float64, spent float64, err error) {
+	var issue Issue
+	if issue, err = session.GetIssue(issueId); err != nil {
+		return
+	}
+	estimated = issue.EstimatedHours
+
+	// The "~" operator matches an issue and all of its descendants
+	filter := map[string]string{"issue_id": strconv.Itoa(issueId)}
+	if includeSubtasks {
+		filter["issue_id"] = "~" + strconv.Itoa(issueId)
+
+		var subtasks []Issue
+		subtasks, err = session.unpaged().GetIssuesWithFilter(map[string]string{
+			"parent_id": "~" + strconv.Itoa(issueId),
+			"status_id": StatusAll})
+		if err != nil {
+			return
+		}
+		for _, subtask := range subtasks {
+			if subtask.Id != issueId {
+				estimated += subtask.EstimatedHours
+			}
+		}
+	}
+
+	var entries []TimeEntry
+	if entries, err = session.unpaged().GetTimeEntriesWithFilter(filter); err != nil {
+		return
+	}
+	for _, entry := range entries {
+		spent += entry.Hours
+	}
+
+	return
+}
+
+// EstimateRollup returns an issue's own estimated hours along with the total
+// estimated hours of its subtasks and their descendants, so that the two can be
+// compared to find over- or under-planned issues. For an issue without
+// subtasks, the subtask total is the issue's own estimate.
+func (session *Session) EstimateRollup(issueId int) (own float64, childrenTotal float64, err error) {
+	var issue Issue
+	if issue, err = session.GetIssueWithIncludes(issueId, "children"); err != nil {
+		return
+	}
+	own = issue.EstimatedHours
+	if len(issue.Children) == 0 {
+		childrenTotal = own
+		return
+	}
+
+	// Redmine 3.3 and later total the estimates of an issue and its
+	// descendants, which saves requesting the descendants
+	if _, ok := issue.Raw["total_estimated_hours"]; ok {
+		childrenTotal = issue.TotalEstimatedHours - own
+		return
+	}
+
+	var subtasks []Issue
+	subtasks, err = session.unpaged().GetIssuesWithFilter(map[string]string{
+		"parent_id": "~" + strconv.Itoa(issueId),
+		"status_id": StatusAll})
+	if err != nil {
+		return
+	}
+	for _, subtask := range subtasks {
+		if subtask.Id != issueId {
+			childrenTotal += subtask.EstimatedHours
+		}
+	}
+	return
+}
+
+// GetProjects returns an array of all the projects the Session user belongs to.
+func (session *Session) GetProjects() ([]Project, error) {
+	var projects []Project
+	if err := session.getAll("/projects.json", nil, "projects", &projects); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// projectListTTL is how long the projects listed by FindProjectByName are
+// kept before they're listed again.
+const projectListTTL = time.Minute
+
+// FindProjectByName returns the project with the given name, ignoring case.
+// It returns an error if no project, or more than one project, has the name.
+// The projects searched are only listed again once they're a minute old.
+func (session *Session) FindProjectByName(name string) (Project, error) {
+	session.cache.Lock()
+	projects := session.cache.projectList
+	fresh := time.Since(session.cache.projectsListed) < projectListTTL
+	session.cache.Unlock()
+
+	if !fresh {
+		var err error
+		if projects, err = session.unpaged().GetProjects(); err != nil {
+			return Project{}, err
+		}
+
+		session.cache.Lock()
+		session.cache.projectList = projects
+		session.cache.projectsListed = time.Now()
+		session.cache.Unlock()
+	}
+
+	var matches []Project
+	for _, project := range projects {
+		if strings.EqualFold(project.Name, name) {
+			matches = append(matches, project)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Project{}, fmt.Errorf("no project named %s", name)
+	case 1:
+		return matches[0], nil
+	}
+	return Project{}, fmt.Errorf("%d projects are named %s", len(matches), name)
+}
+
+// GetProject returns a specific project along with the associated data named
+// by includes, such as "enabled_modules" or "trackers".
+func (session *Session) GetProject(id int, includes ...string) (project Project, err error) {
+	var params map[string]string
+	if len(includes) > 0 {
+		params = map[string]string{"include": strings.Join(includes, ",")}
+	}
+
+	var data []byte
+	if data, err = session.get("/projects/"+strconv.Itoa(id)+".json", params); err != nil {
+		return
+	}
+
+	var p struct {
+		Project Project `json:"project"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err = dec.Decode(&p); err != nil {
+		return
+	}
+	project = p.Project
+	return
+}
+
+// CreateProject creates a new project and returns it.
+func (session *Session) CreateProject(project NewProject) (created Project, err error) {
+	if err = session.create("/projects.json", "project", project, &created); err != nil {
+		var apiErr *APIError
+		if project.Parent != 0 && errors.As(err, &apiErr) {
+			if _, parentErr := session.GetProject(project.Parent); errors.Is(parentErr, ErrNotFound) {
+				err = fmt.Errorf("parent project %d does not exist or is not visible: %w", project.Parent, err)
+			}
+		}
+	}
+	return
+}
+
+// GetProjectsPage returns a single page of projects. The "offset" and "limit"
+// parameters select the page.
+func (session *Session) GetProjectsPage(filter map[string]string) (projects []Project, meta PageMeta, err error) {
+	meta, err = session.getPage("/projects.json", filter, "projects", &projects)
+	return
+}
+
+// GetIssueStatuses returns an array of all the available issue statuses.
+func (session *Session) GetIssueStatuses() ([]IssueStatus, error) {
+	data, err := session.get("/issue_statuses.json", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses struct {
+		IssueStatuses []IssueStatus `json:"issue_statuses"`
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	err = dec.Decode(&statuses)
+	if err != nil {
+		return nil, err
+	}
+
+	return statuses.IssueStatuses, nil
+}
+
+// cachedIssueStatuses returns the available issue statuses, only requesting
+// them from Redmine the first time they're needed.
+func (session *Session) cachedIssueStatuses() ([]IssueStatus, error) {
+	session.cache.Lock()
+	statuses := session.cache.statuses
+	session.cache.Unlock()
+	if statuses != nil {
+		return statuses, nil
+	}
+
+	statuses, err := session.GetIssueStatuses()
+	if err != nil {
+		return nil, err
+	}
+
+	session.cache.Lock()
+	session.cache.statuses = statuses
+	session.cache.Unlock()
+	return statuses, nil
+}
+
+// userIdForLogin returns the id of the user with the given login, only
+// looking it up in Redmine the first time it's needed.
+func (session *Session) userIdForLogin(login string) (int, error) {
+	session.cache.Lock()
+	id, ok := session.cache.logins[login]
+	session.cache.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	users, err := session.GetUsers(map[string]string{"name": login})
+	if err != nil {
+		return 0, err
+	}
+
+	// The name filter also matches partial logins, names, and mail addresses,
+	// so look for the users whose login actually matches
+	var matches []User
+	for _, user := range users {
+		if strings.EqualFold(user.Login, login) {
+			matches = append(matches, user)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return 0, fmt.Errorf("no user with login %s", login)
+	case 1:
+		session.cache.Lock()
+		session.cache.logins[login] = matches[0].Id
+		session.cache.Unlock()
+		return matches[0].Id, nil
+	default:
+		return 0, fmt.Errorf("login %s matches %d users", login, len(matches))
+	}
+}
+
+// cachedProject returns a project along with its trackers, issue categories,
+// and issue custom fields, only requesting it from Redmine the first time
+// it's needed.
+func (session *Session) cachedProject(id int) (Project, error) {
+	session.cache.Lock()
+	project, ok := session.cache.projects[id]
+	session.cache.Unlock()
+	if ok {
+		return project, nil
+	}
+
+	project, err := session.GetProject(id, "trackers", "issue_categories", "issue_custom_fields")
+	if err != nil {
+		return project, err
+	}
+
+	session.cache.Lock()
+	session.cache.projects[id] = project
+	session.cache.Unlock()
+	return project, nil
+}
+
+// validateIssue checks that the tracker and category of a new issue are
+// available in its project, and that it has a value for each required custom
+// field without a default value. Custom fields are only checked if the
+// Session user may list them.
+func (session *Session) validateIssue(issue UpdateIssue) error {
+	if issue.Project == 0 {
+		return nil
+	}
+
+	project, err := session.cachedProject(issue.Project)
+	if err != nil {
+		return err
+	}
+
+	if issue.Tracker != 0 && !hasIdentifier(project.Trackers, issue.Tracker) {
+		return fmt.Errorf("tracker %d is not enabled for project %s, available trackers are %s",
+			issue.Tracker, project.Name, identifierList(project.Trackers))
+	}
+	if issue.Category != 0 && !hasIdentifier(project.IssueCategories, issue.Category) {
+		return fmt.Errorf("category %d does not exist in project %s, available categories are %s",
+			issue.Category, project.Name, identifierList(project.IssueCategories))
+	}
+
+	fields, err := session.cachedCustomFields()
+	if errors.Is(err, ErrForbidden) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	tracker := issue.Tracker
+	if tracker == 0 && len(project.Trackers) > 0 {
+		// Redmine gives new issues the project's first tracker
+		tracker = project.Trackers[0].Id
+	}
+	var missing []string
+	for _, field := range fields {
+		if field.CustomizedType != "issue" || !field.IsRequired || field.DefaultValue != "" {
+			continue
+		}
+		if !hasIdentifier(field.Trackers, tracker) {
+			continue
+		}
+		if !field.IsForAll && !hasIdentifier(project.IssueCustomFields, field.Id) {
+			continue
+		}
+		if !hasValue(issue.CustomFields, field.Id) {
+			missing = append(missing, field.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required custom fields: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// hasValue reports whether a list of custom field values has a non-empty value
+// for the field with the given id.
+func hasValue(fields []ValueField, id int) bool {
+	for _, field := range fields {
+		if field.Id == id && (field.Value != "" || len(field.Values) > 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// support /////////////////////////////////////////////////////////////
+
+func toQueryString(params map[string]string) string {
+	values := url.Values{}
+	for key, value := range params {
+		values.Set(key, value)
 	}
 	return values.Encode()
 }
 
+// dateRange returns the filter value matching the dates between from and to,
+// inclusive.
+func dateRange(from, to time.Time) string {
+	return "><" + from.Format("2006-01-02") + "|" + to.Format("2006-01-02")
+}
+
+func hasIdentifier(identifiers []Identifier, id int) bool {
+	for _, identifier := range identifiers {
+		if identifier.Id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// identifierList returns a readable list of identifiers for error messages.
+func identifierList(identifiers []Identifier) string {
+	if len(identifiers) == 0 {
+		return "none"
+	}
+
+	names := make([]string, len(identifiers))
+	for i, identifier := range identifiers {
+		names[i] = fmt.Sprintf("%s (%d)", identifier.Name, identifier.Id)
+	}
+	return strings.Join(names, ", ")
+}
+
+// forEach calls fn with each index from 0 to n-1, running up to the Session's
+// concurrency limit of calls at once, and returns when all calls are done.
+func (session *Session) forEach(n int, fn func(i int)) {
+	limit := session.concurrency
+	if limit < 1 {
+		limit = 1
+	}
+
+	slots := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			fn(i)
+			<-slots
+		}(i)
+	}
+	wg.Wait()
+}
+
+// projectFilter returns a set of filter parameters that includes the
+// Session's default project if filter doesn't already specify a project or
+// name specific issues.
+func (session *Session) projectFilter(filter map[string]string) map[string]string {
+	if session.defaultProject == 0 {
+		return filter
+	}
+	for _, key := range []string{"project_id", "issue_id", "parent_id"} {
+		if _, ok := filter[key]; ok {
+			return filter
+		}
+	}
+
+	params := map[string]string{"project_id": strconv.Itoa(session.defaultProject)}
+	for key, value := range filter {
+		params[key] = value
+	}
+	return params
+}
+
+// maxPageSize is the largest page size Redmine allows.
+const maxPageSize = 100
+
+// listParams returns a copy of a set of filter parameters with the Session's
+// page size applied, so that paging through results never modifies the
+// caller's filter. A page size outside of the range Redmine supports is
+// clamped to that range.
+func (session *Session) listParams(filter map[string]string) map[string]string {
+	pageSize := maxPageSize
+	if session.pageSize != 0 {
+		pageSize = session.pageSize
+	}
+
+	params := map[string]string{"limit": strconv.Itoa(pageSize)}
+	for key, value := range filter {
+		params[key] = value
+	}
+
+	limit, err := strconv.Atoi(params["limit"])
+	switch {
+	case err != nil:
+		limit = maxPageSize
+	case limit < 1:
+		limit = 1
+	case limit > maxPageSize:
+		limit = maxPageSize
+	}
+	if strconv.Itoa(limit) != params["limit"] {
+		log.Printf("adjusted page size from %q to %d", params["limit"], limit)
+		params["limit"] = strconv.Itoa(limit)
+	}
+
+	return params
+}
+
+// getPage requests a single page of a list and decodes the list items, which
+// are stored in the response under key, into items.
+func (session *Session) getPage(path string, filter map[string]string, key string, items interface{}) (meta PageMeta, err error) {
+	params := session.listParams(filter)
+	decode := func(body io.Reader) error {
+		return decodePage(body, key, items, &meta)
+	}
+
+	if session.responses != nil || session.onResponse != nil {
+		var data []byte
+		if data, err = session.get(path, params); err != nil {
+			return
+		}
+		err = decode(bytes.NewReader(data))
+		return
+	}
+
+	// Pages can be large, so decode them without holding the whole response
+	// in memory
+	err = session.stream(session.url+path+"?"+toQueryString(params), decode)
+	return
+}
+
+// decodePage decodes a page of a list, storing the list items, which are
+// under key, in items and the page's metadata in meta.
+func decodePage(body io.Reader, key string, items interface{}, meta *PageMeta) error {
+	dec := json.NewDecoder(body)
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != json.Delim('{') {
+		return fmt.Errorf("expected a JSON object, got %v", token)
+	}
+
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		var value interface{}
+		switch token {
+		case key:
+			value = items
+		case "total_count":
+			value = &meta.TotalCount
+		case "offset":
+			value = &meta.Offset
+		case "limit":
+			value = &meta.Limit
+		default:
+			value = &json.RawMessage{}
+		}
+		if err := dec.Decode(value); err != nil {
+			return err
+		}
+	}
+
+	_, err = dec.Token()
+	return err
+}
+
+// getAll requests every page of a list and appends the list items, which are
+// stored in each response under key, to the slice pointed to by items. It
+// stops early if the Session's page limit is reached.
+func (session *Session) getAll(path string, filter map[string]string, key string, items interface{}) error {
+	list := reflect.ValueOf(items).Elem()
+	params := session.listParams(filter)
+	offset, _ := strconv.Atoi(params["offset"])
+
+	for pages := 1; ; pages++ {
+		params["offset"] = strconv.Itoa(offset)
+		page := reflect.New(list.Type())
+		meta, err := session.getPage(path, params, key, page.Interface())
+		if err != nil {
+			return err
+		}
+
+		// Stop on an empty page even if the total count says there should be
+		// more items, since the total can shrink while paging if items are
+		// deleted
+		count := page.Elem().Len()
+		list.Set(reflect.AppendSlice(list, page.Elem()))
+		offset += count
+		if count == 0 || offset >= meta.TotalCount || session.lastPage(pages) {
+			return nil
+		}
+	}
+}
+
+// unpaged returns a copy of a Session without a page limit, for methods that
+// would give wrong results from part of a list, such as lookups by name and
+// totals.
+func (session *Session) unpaged() *Session {
+	full := *session
+	full.maxPages = 0
+	return &full
+}
+
+// lastPage returns true if a list request that has retrieved the given number
+// of pages has reached the Session's page limit.
+func (session *Session) lastPage(pages int) bool {
+	return session.maxPages > 0 && pages >= session.maxPages
+}
+
+// marshalWithZeroes encodes the struct v as JSON, then adds back the fields
+// named in force that were dropped because of omitempty. Dropped date and id
+// fields are added as null.
+func marshalWithZeroes(v interface{}, force []string) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(force) == 0 {
+		return data, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	value := reflect.ValueOf(v)
+	for _, name := range force {
+		found := false
+		for i := 0; i < value.NumField(); i++ {
+			tag := strings.Split(value.Type().Field(i).Tag.Get("json"), ",")[0]
+			if tag == name {
+				found = true
+				if _, ok := fields[name]; ok {
+					break
+				}
+				if strings.HasSuffix(name, "_date") || strings.HasSuffix(name, "_id") {
+					fields[name] = json.RawMessage("null")
+				} else if fields[name], err = json.Marshal(value.Field(i).Interface()); err != nil {
+					return nil, err
+				}
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown field %s", name)
+		}
+	}
+
+	return json.Marshal(fields)
+}
+
+// errorMessages returns the messages from a Redmine error response, such as
+// the validation errors for a rejected update.
+func errorMessages(content []byte) []string {
+	var resp struct {
+		Errors []string `json:"errors"`
+	}
+	if err := json.Unmarshal(content, &resp); err != nil {
+		return nil
+	}
+	return resp.Errors
+}
+
+// routeKey is the context key for the templated path of a request.
+type routeKey struct{}
+
+// SpanName returns a name for a request made by a Session that's suitable for
+// a tracing span or metric label. Ids, project identifiers, and wiki page
+// titles in the request path are replaced by placeholders, as in
+// "GET /projects/{project}/wiki/{title}.json". The file names of attachment
+// downloads are replaced as well.
+func SpanName(req *http.Request) string {
+	route, ok := req.Context().Value(routeKey{}).(string)
+	if !ok {
+		route = templatePath(req.URL.Path)
+	}
+	return req.Method + " " + route
+}
+
+// templatePath replaces the variable segments of a Redmine API path with
+// placeholders.
+func templatePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i := 1; i < len(segments); i++ {
+		name, ext := segments[i], ""
+		if dot := strings.LastIndex(name, "."); dot >= 0 {
+			name, ext = name[:dot], name[dot:]
+		}
+
+		switch {
+		case segments[i-1] == "projects":
+			segments[i] = "{project}" + ext
+		case segments[i-1] == "wiki" && name != "index":
+			segments[i] = "{title}" + ext
+		case i >= 3 && segments[i-3] == "attachments" && segments[i-2] == "download":
+			segments[i] = "{filename}"
+		default:
+			if _, err := strconv.Atoi(name); err == nil {
+				segments[i] = "{id}" + ext
+			}
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// Do sends a request to an endpoint this package has no method for, such as
+// one added by a plugin. path is relative to the Redmine URL and may include
+// a query string, as in "/projects/foo/news.json?limit=10". body, if not nil,
+// is sent as JSON, and the response, if any, is decoded into out unless out
+// is nil. Errors are reported as by the other methods.
+func (session *Session) Do(method, path string, body interface{}, out interface{}) error {
+	var data []byte
+	var err error
+	if method == "GET" {
+		data, err = session.get(path, nil)
+	} else {
+		data, err = session.send(method, path, body)
+	}
+	if err != nil {
+		return err
+	}
+
+	if out == nil || len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	return dec.Decode(out)
+}
+
+// httpClient returns the client a Session sends requests with.
+func (session *Session) httpClient() *http.Client {
+	if session.client != nil {
+		return session.client
+	}
+	return client
+}
+
 func (session *Session) request(method string, requestUrl string, body io.Reader) ([]byte, error) {
-	req, err := http.NewRequest(method, requestUrl, body)
+	req, err := session.newRequest(method, requestUrl, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var cacheKey string
+	var cached CachedResponse
+	var haveCached bool
+	if session.responses != nil && method == "GET" {
+		cacheKey = session.responseCacheKey(requestUrl)
+		if cached, haveCached = session.responses.Get(cacheKey); haveCached {
+			if cached.ETag != "" {
+				req.Header.Set("If-None-Match", cached.ETag)
+			}
+			if cached.LastModified != "" {
+				req.Header.Set("If-Modified-Since", cached.LastModified)
+			}
+		}
+	}
+
+	start := time.Now()
+	resp, err := session.do(req, requestUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	content, err := session.readBody(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheKey != "" {
+		if resp.StatusCode == http.StatusNotModified && haveCached {
+			content = cached.Body
+		} else if resp.StatusCode == http.StatusOK && contentError(resp) == nil {
+			etag, modified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+			if etag != "" || modified != "" {
+				session.responses.Set(cacheKey, CachedResponse{ETag: etag, LastModified: modified, Body: content})
+			}
+		}
+	}
+
+	if session.onResponse != nil {
+		// Give the hook its own copy of the body so it can't consume ours
+		hookResp := *resp
+		hookResp.Body = ioutil.NopCloser(bytes.NewReader(content))
+		session.onResponse(&hookResp, time.Since(start))
+	}
+
+	if err := session.responseError(resp, content); err != nil {
+		return content, err
+	}
+	if len(content) == 0 {
+		return content, nil
+	}
+	return content, contentError(resp)
+}
+
+// stream makes a GET request and decodes the response body with decode as
+// it's read, rather than reading the whole body first as request does. It can
+// only be used when nothing else needs the whole body, so the Session must
+// have no response cache or response hook.
+func (session *Session) stream(requestUrl string, decode func(io.Reader) error) error {
+	req, err := session.newRequest("GET", requestUrl, nil)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("GETing from URL: %s", requestUrl)
+	resp, err := session.do(req, requestUrl)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body := session.limitBody(resp)
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		content, err := ioutil.ReadAll(body)
+		if err != nil {
+			return err
+		}
+		return session.responseError(resp, content)
+	}
+	if err := contentError(resp); err != nil {
+		return err
+	}
+	return decode(body)
+}
+
+// readBody reads a response body, up to the Session's limit on response size.
+func (session *Session) readBody(resp *http.Response) ([]byte, error) {
+	return ioutil.ReadAll(session.limitBody(resp))
+}
+
+// limitBody returns a reader for a response body that fails once more than
+// the Session's limit on response size has been read.
+func (session *Session) limitBody(resp *http.Response) io.Reader {
+	limit := session.maxResponseBytes
+	if limit == 0 {
+		limit = defaultMaxResponseBytes
+	}
+	if limit < 0 {
+		return resp.Body
+	}
+
+	path := ""
+	if resp.Request != nil {
+		path = resp.Request.URL.Path + ": "
+	}
+	return &limitedReader{
+		reader:    resp.Body,
+		remaining: limit,
+		err:       fmt.Errorf("%sresponse is larger than the limit of %d bytes", path, limit),
+	}
+}
+
+// limitedReader reads from reader until more than remaining bytes have been
+// read, and then returns err.
+type limitedReader struct {
+	reader    io.Reader
+	remaining int64
+	err       error
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if r.remaining < 0 {
+		return 0, r.err
+	}
+	// Read one byte past the limit so that going over it is noticed
+	if int64(len(p)) > r.remaining+1 {
+		p = p[:r.remaining+1]
+	}
+	n, err := r.reader.Read(p)
+	r.remaining -= int64(n)
+	if r.remaining < 0 {
+		return 0, r.err
+	}
+	return n, err
+}
+
+// contentError returns an error if a successful response has a Content-Type
+// other than JSON, as happens when the Redmine URL is wrong or a proxy in
+// front of Redmine responds with a login page.
+func contentError(resp *http.Response) error {
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" || resp.StatusCode == http.StatusNotModified {
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")) {
+		return nil
+	}
+	path := ""
+	if resp.Request != nil {
+		path = resp.Request.URL.Path + ": "
+	}
+	return fmt.Errorf("%sexpected JSON, got %s; check the Redmine URL and credentials", path, contentType)
+}
+
+// newRequest creates a request to Redmine with the Session's context,
+// headers, and credentials.
+func (session *Session) newRequest(method string, requestUrl string, body io.Reader) (*http.Request, error) {
+	ctx := session.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, requestUrl, body)
+	if err != nil {
+		return nil, err
+	}
 	req.Header.Add("Content-Type", "application/json")
 
-	if session.apiKey != "" {
+	route := strings.SplitN(strings.TrimPrefix(requestUrl, session.url), "?", 2)[0]
+	req = req.WithContext(context.WithValue(req.Context(), routeKey{}, templatePath(route)))
+
+	for key, values := range session.headers {
+		if http.CanonicalHeaderKey(key) == "X-Redmine-Api-Key" {
+			continue
+		}
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if req.Header.Get("User-Agent") == "" {
+		userAgent := session.userAgent
+		if userAgent == "" {
+			userAgent = DefaultUserAgent
+		}
+		req.Header.Set("User-Agent", userAgent)
+	}
+
+	if session.apiKey != "" && session.authMode == AuthQueryParam {
+		log.Printf("using api key in query string")
+		query := req.URL.Query()
+		query.Set("key", session.apiKey)
+		req.URL.RawQuery = query.Encode()
+	} else if session.apiKey != "" {
 		log.Printf("using api key: %s", session.apiKey)
 		req.Header.Add("X-Redmine-API-Key", session.apiKey)
 	} else {
@@ -383,22 +2425,54 @@ func (session *Session) request(method string, requestUrl string, body io.Reader
 		req.SetBasicAuth(session.username, session.password)
 	}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
+	if session.switchUser != "" {
+		req.Header.Set("X-Redmine-Switch-User", session.switchUser)
+	}
+
+	return req, nil
+}
+
+// do sends a request created by newRequest. requestUrl is the URL the request
+// was created with, which is used in errors in place of the request's own URL
+// since that may contain the API key.
+func (session *Session) do(req *http.Request, requestUrl string) (*http.Response, error) {
+	if session.onRequest != nil {
+		session.onRequest(req)
 	}
-	defer resp.Body.Close()
 
-	content, err := ioutil.ReadAll(resp.Body)
+	resp, err := session.httpClient().Do(req)
 	if err != nil {
+		if urlErr, ok := err.(*url.Error); ok {
+			// Don't leak an API key in the query string into error messages
+			urlErr.URL = requestUrl
+		}
 		return nil, err
 	}
+	return resp, nil
+}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
-		return content, fmt.Errorf(resp.Status)
+// responseError returns the error for a response with an error status, or nil
+// if the response was successful.
+func (session *Session) responseError(resp *http.Response, content []byte) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 400 {
+		return nil
 	}
 
-	return content, nil
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Messages:   errorMessages(content),
+	}
+	if resp.Request != nil {
+		apiErr.Path = resp.Request.URL.Path
+	}
+
+	if resp.StatusCode == http.StatusPreconditionFailed && session.switchUser != "" {
+		// Redmine responds with 412 when the user to switch to doesn't exist
+		// or isn't active
+		return fmt.Errorf("unable to impersonate user %s: %w", session.switchUser, apiErr)
+	}
+	return apiErr
 }
 
 func (session *Session) get(path string, params map[string]string) ([]byte, error) {
@@ -425,6 +2499,10 @@ func (session *Session) send(method, path string, data interface{}) ([]byte, err
 		}
 	}
 
+	if session.dryRun {
+		return nil, &DryRunError{Method: method, Url: requestUrl, Body: body}
+	}
+
 	log.Printf(method+"ing to URL %s: %s", requestUrl, string(body))
 	return session.request(method, requestUrl, bytes.NewBuffer(body))
 }
@@ -433,6 +2511,31 @@ func (session *Session) post(path string, data interface{}) ([]byte, error) {
 	return session.send("POST", path, data)
 }
 
+// create posts a new object to Redmine, sent under key, and decodes the
+// created object, which Redmine returns under the same key, into created.
+func (session *Session) create(path, key string, object interface{}, created interface{}) error {
+	resp, err := session.post(path, map[string]interface{}{key: object})
+	if err != nil {
+		return err
+	}
+
+	var body map[string]json.RawMessage
+	if len(bytes.TrimSpace(resp)) > 0 {
+		if err := json.Unmarshal(resp, &body); err != nil {
+			return err
+		}
+	}
+	content, ok := body[key]
+	if !ok {
+		return fmt.Errorf("%s was created but Redmine did not return it", strings.Replace(key, "_", " ", -1))
+	}
+	return json.Unmarshal(content, created)
+}
+
 func (session *Session) put(path string, data interface{}) ([]byte, error) {
 	return session.send("PUT", path, data)
 }
+
+func (session *Session) delete(path string) ([]byte, error) {
+	return session.send("DELETE", path, nil)
+}
@@ -0,0 +1,537 @@
+package redmine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	// Sessions log every request
+	log.SetOutput(ioutil.Discard)
+	os.Exit(m.Run())
+}
+
+func TestGetIssuesStopsOnEmptyPage(t *testing.T) {
+	// Issues 3 and 4 are deleted after the first page is returned, but the
+	// total count still includes them
+	var offsets []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset := r.URL.Query().Get("offset")
+		offsets = append(offsets, offset)
+		if len(offsets) > 3 {
+			t.Errorf("too many requests, offsets %v", offsets)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		issues := ""
+		if offset == "0" {
+			issues = `{"id":1},{"id":2}`
+		}
+		fmt.Fprintf(w, `{"issues":[%s],"total_count":4,"offset":%s,"limit":2}`, issues, offset)
+	}))
+	defer server.Close()
+
+	session := OpenSession(server.URL, "key")
+	session.SetPageSize(2)
+	issues, err := session.GetIssuesWithFilter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 2 {
+		t.Errorf("got %d issues, want 2", len(issues))
+	}
+	if len(offsets) != 2 {
+		t.Errorf("made %d requests, want 2", len(offsets))
+	}
+}
+
+// TestConcurrentUse is meant to be run with the race detector.
+func TestConcurrentUse(t *testing.T) {
+	session := NewFixtureSession(Fixtures{
+		"/issues/1.json":       `{"issue":{"id":1,"status":{"id":1},"author":{"id":3}}}`,
+		"/issue_statuses.json": `{"issue_statuses":[{"id":1,"name":"New"}]}`,
+		"/users.json":          `{"users":[{"id":3,"firstname":"Ann"}],"total_count":1}`,
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			issue, err := session.GetIssue(1)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			issues := []Issue{issue}
+			if err := session.ResolveNames(issues); err != nil {
+				t.Error(err)
+				return
+			}
+			if issues[0].Status.Name != "New" || issues[0].Author.Name != "Ann" {
+				t.Errorf("got status %q and author %q", issues[0].Status.Name, issues[0].Author.Name)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestGetIssueWithIncludesWatchers(t *testing.T) {
+	session := NewFixtureSession(Fixtures{
+		"/issues/1.json": `{"issue":{"id":1,"watchers":[{"id":3,"name":"Ann"},{"id":4,"name":"Bob"}]}}`,
+		"/issues/2.json": `{"issue":{"id":2}}`,
+	})
+
+	issue, err := session.GetIssueWithIncludes(1, "watchers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issue.Watchers) != 2 || issue.Watchers[1].Name != "Bob" {
+		t.Errorf("got watchers %v", issue.Watchers)
+	}
+
+	// Watchers are omitted when the Session user can't see them
+	issue, err = session.GetIssueWithIncludes(2, "watchers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issue.Watchers) != 0 {
+		t.Errorf("got watchers %v, want none", issue.Watchers)
+	}
+}
+
+func TestUpdateIssueJSON(t *testing.T) {
+	isPrivate, isPublic := true, false
+	tests := []struct {
+		name   string
+		update UpdateIssue
+		want   string
+	}{
+		{"zero values are omitted",
+			UpdateIssue{Subject: "x", DoneRatio: 0},
+			`{"subject":"x"}`},
+		{"done ratio is cleared",
+			UpdateIssue{ForceSend: []string{"done_ratio"}},
+			`{"done_ratio":0}`},
+		{"due date is cleared",
+			UpdateIssue{ForceSend: []string{"due_date"}},
+			`{"due_date":null}`},
+		{"issue is made private",
+			UpdateIssue{IsPrivate: &isPrivate},
+			`{"is_private":true}`},
+		{"issue is made public",
+			UpdateIssue{IsPrivate: &isPublic},
+			`{"is_private":false}`},
+		{"custom field values",
+			UpdateIssue{CustomFields: []ValueField{
+				{Identifier: Identifier{Id: 1}, Value: "a"},
+				{Identifier: Identifier{Id: 2}, Values: []string{"b", "c"}}}},
+			`{"custom_fields":[{"id":1,"value":"a"},{"id":2,"value":["b","c"]}]}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := json.Marshal(test.update)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got, want map[string]interface{}
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(test.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %s, want %s", data, test.want)
+			}
+		})
+	}
+}
+
+func TestPublicJournals(t *testing.T) {
+	session := NewFixtureSession(Fixtures{
+		"/issues/1.json": `{"issue":{"id":1,"journals":[
+			{"id":10,"notes":"public","private_notes":false},
+			{"id":11,"notes":"private","private_notes":true},
+			{"id":12,"notes":"also public"}]}}`,
+	})
+
+	issue, err := session.GetIssueWithIncludes(1, "journals")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issue.Journals) != 3 || !issue.Journals[1].PrivateNotes {
+		t.Fatalf("got journals %+v", issue.Journals)
+	}
+
+	public := issue.PublicJournals()
+	if len(public) != 2 || public[0].Id != 10 || public[1].Id != 12 {
+		t.Errorf("got public journals %+v, want 10 and 12", public)
+	}
+}
+
+func TestGetProjectsMultiValueCustomFields(t *testing.T) {
+	session := NewFixtureSession(Fixtures{
+		"/projects.json": `{"projects":[
+			{"id":1,"name":"One","custom_fields":[
+				{"id":5,"name":"Owner","value":"ann"},
+				{"id":6,"name":"Platforms","multiple":true,"value":["linux","windows"]},
+				{"id":7,"name":"Empty","value":null}]},
+			{"id":2,"name":"Two"}],"total_count":2}`,
+	})
+
+	projects, err := session.GetProjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 2 {
+		t.Fatalf("got %d projects, want 2", len(projects))
+	}
+
+	want := []ValueField{
+		{Identifier: Identifier{Id: 5, Name: "Owner"}, Value: "ann"},
+		{Identifier: Identifier{Id: 6, Name: "Platforms"}, Values: []string{"linux", "windows"}},
+		{Identifier: Identifier{Id: 7, Name: "Empty"}},
+	}
+	if got := projects[0].CustomFields; !reflect.DeepEqual(got, want) {
+		t.Errorf("got custom fields %+v, want %+v", got, want)
+	}
+}
+
+func TestGetTimeEntriesMultiValueCustomFields(t *testing.T) {
+	session := NewFixtureSession(Fixtures{
+		"/time_entries.json": `{"time_entries":[
+			{"id":1,"hours":2,"spent_on":"2024-03-04","custom_fields":[
+				{"id":8,"name":"Tags","multiple":true,"value":["review","support"]}]},
+			{"id":2,"hours":1,"spent_on":"2024-03-05","custom_fields":[
+				{"id":8,"name":"Tags","multiple":true,"value":[]}]}],
+			"total_count":2}`,
+	})
+
+	entries, err := session.GetTimeEntriesWithFilter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d time entries, want 2", len(entries))
+	}
+	if got := entries[0].CustomFields; len(got) != 1 ||
+		!reflect.DeepEqual(got[0].Values, []string{"review", "support"}) {
+		t.Errorf("got custom fields %+v for entry 1", got)
+	}
+	if got := entries[1].CustomFields; len(got) != 1 || len(got[0].Values) != 0 || got[0].Value != "" {
+		t.Errorf("got custom fields %+v for entry 2", got)
+	}
+}
+
+// newPagingServer returns a server that answers list requests with the page
+// of items, which are JSON objects stored under key, at the requested offset
+// and limit.
+func newPagingServer(key string, items []string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		end := offset + limit
+		if offset > len(items) {
+			offset = len(items)
+		}
+		if end > len(items) {
+			end = len(items)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"%s":[%s],"total_count":%d,"offset":%d,"limit":%d}`,
+			key, strings.Join(items[offset:end], ","), len(items), offset, limit)
+	}))
+}
+
+func TestFindProjectByNameIgnoresPageLimit(t *testing.T) {
+	server := newPagingServer("projects", []string{
+		`{"id":1,"name":"One"}`, `{"id":2,"name":"Two"}`, `{"id":3,"name":"Three"}`, `{"id":4,"name":"one"}`})
+	defer server.Close()
+
+	session := OpenSession(server.URL, "key")
+	session.SetPageSize(2)
+	session.SetMaxPages(1)
+
+	project, err := session.FindProjectByName("three")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if project.Id != 3 {
+		t.Errorf("got project %d, want 3", project.Id)
+	}
+	if _, err := session.FindProjectByName("One"); err == nil {
+		t.Error("got no error for a name shared by two projects")
+	}
+	if projects, _ := session.GetProjects(); len(projects) != 2 {
+		t.Errorf("got %d projects from GetProjects, want the page limit of 2", len(projects))
+	}
+}
+
+func TestReassignIssuesIgnoresPageLimit(t *testing.T) {
+	issues := newPagingServer("issues", []string{`{"id":1}`, `{"id":2}`, `{"id":3}`})
+	defer issues.Close()
+
+	var mu sync.Mutex
+	updated := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			issues.Config.Handler.ServeHTTP(w, r)
+			return
+		}
+		mu.Lock()
+		updated[r.URL.Path] = true
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	session := OpenSession(server.URL, "key")
+	session.SetPageSize(2)
+	session.SetMaxPages(1)
+
+	count, err := session.ReassignIssues(5, 6, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 || len(updated) != 3 {
+		t.Errorf("reassigned %d issues, updated %v, want all 3", count, updated)
+	}
+}
+
+func TestWaitForStatusInterval(t *testing.T) {
+	server, bodies := newRecordingServer()
+	defer server.Close()
+
+	session := OpenSession(server.URL, "key")
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if err := session.WaitForStatus(context.Background(), 1, 5, interval); err == nil {
+			t.Errorf("got no error for interval %v", interval)
+		}
+	}
+	if len(*bodies) != 0 {
+		t.Errorf("made %d requests, want none", len(*bodies))
+	}
+}
+
+// newRecordingServer returns a server that answers every request with an empty
+// success response and records the bodies of the requests it receives.
+func newRecordingServer() (*httptest.Server, *[]string) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	return server, &bodies
+}
+
+func TestUpdateIssueDueDate(t *testing.T) {
+	server, bodies := newRecordingServer()
+	defer server.Close()
+
+	session := OpenSession(server.URL, "key")
+	if err := session.UpdateIssue(1, UpdateIssue{DueDate: "2024-05-01"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := session.UpdateIssue(1, UpdateIssue{ForceSend: []string{"due_date"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{`{"issue":{"due_date":"2024-05-01"}}`, `{"issue":{"due_date":null}}`}
+	if !reflect.DeepEqual(*bodies, want) {
+		t.Errorf("got requests %q, want %q", *bodies, want)
+	}
+}
+
+func TestCreateTimeEntryForProject(t *testing.T) {
+	session := NewFixtureSession(Fixtures{
+		"POST /time_entries.json": `{"time_entry":{"id":5,"hours":1.5,"project":{"id":7}}}`,
+	})
+
+	entry, err := session.CreateTimeEntry(NewTimeEntry{Project: 7, Hours: 1.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Id != 5 || entry.Project.Id != 7 {
+		t.Errorf("got time entry %+v", entry)
+	}
+
+	session.SetDryRun(true)
+	_, err = session.CreateTimeEntry(NewTimeEntry{Project: 7, Hours: 1.5})
+	var dryRun *DryRunError
+	if !errors.As(err, &dryRun) {
+		t.Fatalf("got error %v, want a DryRunError", err)
+	}
+	if want := `{"time_entry":{"project_id":7,"hours":1.5}}`; string(dryRun.Body) != want {
+		t.Errorf("sent %s, want %s", dryRun.Body, want)
+	}
+
+	_, err = session.CreateTimeEntry(NewTimeEntry{Hours: 1.5})
+	if err == nil || errors.As(err, &dryRun) {
+		t.Errorf("got error %v for a time entry without an issue or project", err)
+	}
+}
+
+func TestCreateReturnsId(t *testing.T) {
+	session := NewFixtureSession(Fixtures{
+		"POST /issues.json":                 `{"issue":{"id":11,"subject":"New"}}`,
+		"POST /time_entries.json":           `{"time_entry":{"id":12,"hours":1}}`,
+		"POST /projects.json":               `{"project":{"id":13,"name":"New"}}`,
+		"POST /projects/1/memberships.json": `{"membership":{"id":14,"user":{"id":3}}}`,
+	})
+
+	tests := []struct {
+		name   string
+		create func() (int, error)
+		want   int
+	}{
+		{"issue", func() (int, error) {
+			issue, err := session.CreateIssue(UpdateIssue{Project: 1, Subject: "New"})
+			return issue.Id, err
+		}, 11},
+		{"time entry", func() (int, error) {
+			entry, err := session.CreateTimeEntry(NewTimeEntry{Issue: 11, Hours: 1})
+			return entry.Id, err
+		}, 12},
+		{"project", func() (int, error) {
+			project, err := session.CreateProject(NewProject{Name: "New", Identifier: "new"})
+			return project.Id, err
+		}, 13},
+		{"membership", func() (int, error) {
+			membership, err := session.AddProjectMember(1, 3, []int{1})
+			return membership.Id, err
+		}, 14},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			id, err := test.create()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id != test.want {
+				t.Errorf("got id %d, want %d", id, test.want)
+			}
+		})
+	}
+}
+
+func TestCreateWithoutBody(t *testing.T) {
+	server, _ := newRecordingServer()
+	defer server.Close()
+
+	session := OpenSession(server.URL, "key")
+	if _, err := session.CreateIssue(UpdateIssue{Project: 1, Subject: "New"}); err == nil {
+		t.Error("got no error for a created issue that wasn't returned")
+	}
+}
+
+func TestWatchedIssueIdsIgnoresPageSettings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if limit := r.URL.Query().Get("limit"); limit != "100" {
+			t.Errorf("requested a page of %s issues, want 100", limit)
+		}
+		// Every issue with an even id is watched
+		var watched []string
+		for _, id := range strings.Split(r.URL.Query().Get("issue_id"), ",") {
+			if n, _ := strconv.Atoi(id); n%2 == 0 {
+				watched = append(watched, `{"id":`+id+`}`)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"issues":[%s],"total_count":%d,"offset":0,"limit":100}`,
+			strings.Join(watched, ","), len(watched))
+	}))
+	defer server.Close()
+
+	session := OpenSession(server.URL, "key")
+	session.SetPageSize(10)
+	session.SetMaxPages(1)
+
+	issues := make([]Issue, 150)
+	for i := range issues {
+		issues[i].Id = i + 1
+	}
+	watched, err := session.WatchedIssueIds(issues)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(watched) != 75 {
+		t.Errorf("got %d watched issues, want 75", len(watched))
+	}
+}
+
+func TestSetInsecureSkipVerify(t *testing.T) {
+	session := OpenSession("https://redmine.test", "key")
+	if err := session.SetInsecureSkipVerify(true); err != nil {
+		t.Fatal(err)
+	}
+	transport, ok := session.httpClient().Transport.(*http.Transport)
+	if !ok || !transport.TLSClientConfig.InsecureSkipVerify {
+		t.Errorf("got transport %#v", session.httpClient().Transport)
+	}
+
+	// A custom transport is kept rather than replaced
+	fixtures := NewFixtureSession(Fixtures{})
+	if err := fixtures.SetInsecureSkipVerify(true); err == nil {
+		t.Error("got no error for a Fixtures transport")
+	}
+	if _, ok := fixtures.httpClient().Transport.(Fixtures); !ok {
+		t.Errorf("transport was replaced by %T", fixtures.httpClient().Transport)
+	}
+}
+
+func TestTemplatePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/issues/12.json", "/issues/{id}.json"},
+		{"/projects/foo/wiki/Start.json", "/projects/{project}/wiki/{title}.json"},
+		{"/projects/foo/wiki/index.json", "/projects/{project}/wiki/index.json"},
+		{"/attachments/download/7/report.pdf", "/attachments/download/{id}/{filename}"},
+		{"/attachments/7.json", "/attachments/{id}.json"},
+	}
+
+	for _, test := range tests {
+		if got := templatePath(test.path); got != test.want {
+			t.Errorf("templatePath(%q) = %q, want %q", test.path, got, test.want)
+		}
+	}
+}
+
+func TestWithTracingTransport(t *testing.T) {
+	var spans []string
+	session := OpenSession("http://redmine.test", "key")
+	session.SetTransport(WithTracingTransport(
+		Fixtures{"/issues/3.json": `{"issue":{"id":3}}`},
+		func(ctx context.Context, name string) (context.Context, func(*http.Response, error)) {
+			return ctx, func(resp *http.Response, err error) {
+				spans = append(spans, fmt.Sprintf("%s %d", name, resp.StatusCode))
+			}
+		}))
+
+	if _, err := session.GetIssue(3); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"GET /issues/{id}.json 200"}; !reflect.DeepEqual(spans, want) {
+		t.Errorf("got spans %q, want %q", spans, want)
+	}
+}
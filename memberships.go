@@ -0,0 +1,104 @@
+package redmine
+
+import (
+	"strconv"
+)
+
+// Membership represents a user's or group's membership in a project. Only one
+// of User and Group is set.
+type Membership struct {
+	Id      int          `json:"id"`
+	Project Identifier   `json:"project"`
+	User    Identifier   `json:"user,omitempty"`
+	Group   Identifier   `json:"group,omitempty"`
+	Roles   []Identifier `json:"roles"`
+}
+
+// GetProjectMemberships returns the memberships of a project.
+func (session *Session) GetProjectMemberships(projectId int) ([]Membership, error) {
+	var memberships []Membership
+	path := "/projects/" + strconv.Itoa(projectId) + "/memberships.json"
+	if err := session.getAll(path, nil, "memberships", &memberships); err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+// AddProjectMember adds a user to a project with the roles with the given ids
+// and returns the new membership.
+func (session *Session) AddProjectMember(projectId, userId int, roleIds []int) (Membership, error) {
+	return session.addProjectMember(projectId, userId, roleIds)
+}
+
+// AddProjectGroupMember adds a group to a project with the roles with the
+// given ids and returns the new membership. The group's users become members
+// of the project through the group.
+func (session *Session) AddProjectGroupMember(projectId, groupId int, roleIds []int) (Membership, error) {
+	// Redmine takes the ids of users and groups in the same field
+	return session.addProjectMember(projectId, groupId, roleIds)
+}
+
+// UpdateMembershipRoles replaces the roles of a membership with the roles
+// with the given ids.
+func (session *Session) UpdateMembershipRoles(membershipId int, roleIds []int) error {
+	data := map[string]interface{}{
+		"membership": map[string]interface{}{
+			"role_ids": roleIds,
+		},
+	}
+	_, err := session.put("/memberships/"+strconv.Itoa(membershipId)+".json", data)
+	return err
+}
+
+// DeleteMembership removes a user or group from a project.
+func (session *Session) DeleteMembership(membershipId int) error {
+	_, err := session.delete("/memberships/" + strconv.Itoa(membershipId) + ".json")
+	return err
+}
+
+func (session *Session) addProjectMember(projectId, principalId int, roleIds []int) (membership Membership, err error) {
+	data := map[string]interface{}{
+		"user_id":  principalId,
+		"role_ids": roleIds,
+	}
+	err = session.create("/projects/"+strconv.Itoa(projectId)+"/memberships.json", "membership", data, &membership)
+	return
+}
+
+// GetProjectsForUser returns the projects a user is a member of, whether
+// directly or through a group. Public projects the user can see without being
+// a member aren't included. Projects the Session user can't see only have
+// their Id and Name set.
+func (session *Session) GetProjectsForUser(userId int) ([]Project, error) {
+	user, err := session.GetUserById(userId, "memberships")
+	if err != nil {
+		return nil, err
+	}
+
+	visible, err := session.unpaged().GetProjects()
+	if err != nil {
+		return nil, err
+	}
+	byId := map[int]Project{}
+	for _, project := range visible {
+		byId[project.Id] = project
+	}
+
+	var projects []Project
+	seen := map[int]bool{}
+	for _, membership := range user.Memberships {
+		id := membership.Project.Id
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		project, ok := byId[id]
+		if !ok {
+			project = Project{Id: id, Name: membership.Project.Name}
+		}
+		projects = append(projects, project)
+	}
+
+	return projects, nil
+}
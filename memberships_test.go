@@ -0,0 +1,41 @@
+package redmine
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGetProjectMembershipsPages(t *testing.T) {
+	const total = 150
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+		var memberships []string
+		for id := offset + 1; id <= total && id <= offset+limit; id++ {
+			memberships = append(memberships, fmt.Sprintf(`{"id":%d,"user":{"id":%d}}`, id, id))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"memberships":[%s],"total_count":%d,"offset":%d,"limit":%d}`,
+			strings.Join(memberships, ","), total, offset, limit)
+	}))
+	defer server.Close()
+
+	session := OpenSession(server.URL, "key")
+	memberships, err := session.GetProjectMemberships(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(memberships) != total {
+		t.Fatalf("got %d memberships, want %d", len(memberships), total)
+	}
+	for i, membership := range memberships {
+		if membership.Id != i+1 {
+			t.Fatalf("membership %d has id %d", i, membership.Id)
+		}
+	}
+}
@@ -0,0 +1,472 @@
+package redmine
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// userListTTL is how long the users listed for resolving names are kept
+// before they're listed again.
+const userListTTL = 10 * time.Minute
+
+// Tracker represents one of the issue trackers configured in Redmine.
+type Tracker struct {
+	Id            int        `json:"id"`
+	Name          string     `json:"name"`
+	DefaultStatus Identifier `json:"default_status"`
+}
+
+// IssuePriority represents one of the issue priorities configured in Redmine.
+type IssuePriority struct {
+	Id        int    `json:"id"`
+	Name      string `json:"name"`
+	IsDefault bool   `json:"is_default"`
+}
+
+// TimeEntryActivity represents one of the time entry activities configured in
+// Redmine.
+type TimeEntryActivity struct {
+	Id        int    `json:"id"`
+	Name      string `json:"name"`
+	IsDefault bool   `json:"is_default"`
+}
+
+// CustomField represents the definition of a custom field configured in
+// Redmine. CustomizedType is the kind of object the field is for, such as
+// "issue", "project", or "time_entry". Trackers is the trackers an issue
+// field is used for, and IsForAll is true if an issue field is used in every
+// project rather than only those it's enabled in.
+type CustomField struct {
+	Id             int          `json:"id"`
+	Name           string       `json:"name"`
+	CustomizedType string       `json:"customized_type"`
+	FieldFormat    string       `json:"field_format"`
+	IsRequired     bool         `json:"is_required"`
+	IsForAll       bool         `json:"is_for_all"`
+	Multiple       bool         `json:"multiple"`
+	DefaultValue   string       `json:"default_value"`
+	Trackers       []Identifier `json:"trackers"`
+}
+
+// GetCustomFields returns the definitions of all custom fields. Listing custom
+// fields requires administrator privileges.
+func (session *Session) GetCustomFields() ([]CustomField, error) {
+	data, err := session.get("/custom_fields.json", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields struct {
+		CustomFields []CustomField `json:"custom_fields"`
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	err = dec.Decode(&fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return fields.CustomFields, nil
+}
+
+// GetTrackers returns an array of all the available trackers.
+func (session *Session) GetTrackers() ([]Tracker, error) {
+	data, err := session.get("/trackers.json", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var trackers struct {
+		Trackers []Tracker `json:"trackers"`
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	err = dec.Decode(&trackers)
+	if err != nil {
+		return nil, err
+	}
+
+	return trackers.Trackers, nil
+}
+
+// GetProjectTrackers returns the trackers enabled for a project, which are
+// the trackers its new issues may have. Each project's trackers are only
+// requested from Redmine the first time they're needed.
+func (session *Session) GetProjectTrackers(projectId int) ([]Tracker, error) {
+	project, err := session.cachedProject(projectId)
+	if err != nil {
+		return nil, err
+	}
+	all, err := session.cachedTrackers()
+	if err != nil {
+		return nil, err
+	}
+
+	trackers := []Tracker{}
+	for _, enabled := range project.Trackers {
+		tracker := Tracker{Id: enabled.Id, Name: enabled.Name}
+		for _, t := range all {
+			if t.Id == enabled.Id {
+				tracker = t
+			}
+		}
+		trackers = append(trackers, tracker)
+	}
+	return trackers, nil
+}
+
+// GetIssuePriorities returns an array of all the available issue priorities.
+func (session *Session) GetIssuePriorities() ([]IssuePriority, error) {
+	data, err := session.get("/enumerations/issue_priorities.json", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var priorities struct {
+		IssuePriorities []IssuePriority `json:"issue_priorities"`
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	err = dec.Decode(&priorities)
+	if err != nil {
+		return nil, err
+	}
+
+	return priorities.IssuePriorities, nil
+}
+
+// GetTimeEntryActivities returns an array of all the available time entry
+// activities.
+func (session *Session) GetTimeEntryActivities() ([]TimeEntryActivity, error) {
+	data, err := session.get("/enumerations/time_entry_activities.json", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var activities struct {
+		TimeEntryActivities []TimeEntryActivity `json:"time_entry_activities"`
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	err = dec.Decode(&activities)
+	if err != nil {
+		return nil, err
+	}
+
+	return activities.TimeEntryActivities, nil
+}
+
+// RefreshMetadata retrieves the issue statuses, issue priorities, trackers,
+// and time entry activities from Redmine and caches them in the Session,
+// replacing anything cached earlier. Afterwards, the Cached methods return
+// them without making any requests.
+func (session *Session) RefreshMetadata() error {
+	statuses, err := session.GetIssueStatuses()
+	if err != nil {
+		return err
+	}
+	priorities, err := session.GetIssuePriorities()
+	if err != nil {
+		return err
+	}
+	trackers, err := session.GetTrackers()
+	if err != nil {
+		return err
+	}
+	activities, err := session.GetTimeEntryActivities()
+	if err != nil {
+		return err
+	}
+
+	session.cache.Lock()
+	defer session.cache.Unlock()
+	session.cache.statuses = statuses
+	session.cache.priorities = priorities
+	session.cache.trackers = trackers
+	session.cache.activities = activities
+	return nil
+}
+
+// CachedIssueStatuses returns the issue statuses cached by the Session, or nil
+// if they haven't been retrieved yet.
+func (session *Session) CachedIssueStatuses() []IssueStatus {
+	session.cache.Lock()
+	defer session.cache.Unlock()
+	return session.cache.statuses
+}
+
+// CachedIssuePriorities returns the issue priorities cached by the Session,
+// or nil if they haven't been retrieved yet.
+func (session *Session) CachedIssuePriorities() []IssuePriority {
+	session.cache.Lock()
+	defer session.cache.Unlock()
+	return session.cache.priorities
+}
+
+// CachedTrackers returns the trackers cached by the Session, or nil if they
+// haven't been retrieved yet.
+func (session *Session) CachedTrackers() []Tracker {
+	session.cache.Lock()
+	defer session.cache.Unlock()
+	return session.cache.trackers
+}
+
+// CachedTimeEntryActivities returns the time entry activities cached by the
+// Session, or nil if they haven't been retrieved yet.
+func (session *Session) CachedTimeEntryActivities() []TimeEntryActivity {
+	session.cache.Lock()
+	defer session.cache.Unlock()
+	return session.cache.activities
+}
+
+// StatusIdByName returns the id of the issue status with the given name,
+// ignoring case. The statuses are cached by the Session.
+func (session *Session) StatusIdByName(name string) (int, error) {
+	statuses, err := session.cachedIssueStatuses()
+	if err != nil {
+		return 0, err
+	}
+
+	names := make([]string, len(statuses))
+	for i, status := range statuses {
+		if strings.EqualFold(status.Name, name) {
+			return status.Id, nil
+		}
+		names[i] = status.Name
+	}
+
+	return 0, fmt.Errorf("no issue status named %s, available statuses are %s",
+		name, strings.Join(names, ", "))
+}
+
+// ResolveNames fills in the names of the statuses, priorities, trackers,
+// authors, and assignees of issues that only have ids. Names that are already
+// set are left alone. The lookup tables used are cached by the Session, so
+// resolving names for more issues later costs few, if any, requests. Users the
+// Session user can't see are left without names.
+func (session *Session) ResolveNames(issues []Issue) error {
+	for i := range issues {
+		issue := &issues[i]
+
+		if issue.Status.Name == "" && issue.Status.Id != 0 {
+			statuses, err := session.cachedIssueStatuses()
+			if err != nil {
+				return err
+			}
+			for _, status := range statuses {
+				if status.Id == issue.Status.Id {
+					issue.Status.Name = status.Name
+				}
+			}
+		}
+
+		if issue.Priority.Name == "" && issue.Priority.Id != 0 {
+			priorities, err := session.cachedIssuePriorities()
+			if err != nil {
+				return err
+			}
+			for _, priority := range priorities {
+				if priority.Id == issue.Priority.Id {
+					issue.Priority.Name = priority.Name
+				}
+			}
+		}
+
+		if issue.Tracker.Name == "" && issue.Tracker.Id != 0 {
+			trackers, err := session.cachedTrackers()
+			if err != nil {
+				return err
+			}
+			for _, tracker := range trackers {
+				if tracker.Id == issue.Tracker.Id {
+					issue.Tracker.Name = tracker.Name
+				}
+			}
+		}
+
+		for _, user := range []*Identifier{&issue.Author, &issue.AssignedTo} {
+			if user.Name != "" || user.Id == 0 {
+				continue
+			}
+			name, err := session.userName(user.Id)
+			if err != nil {
+				return err
+			}
+			user.Name = name
+		}
+	}
+
+	return nil
+}
+
+// EnrichContacts fills in the AuthorMail and AssignedToMail of issues. Users
+// are looked up as for ResolveNames, so the mail addresses of many users cost
+// few requests. Mail is left empty for groups and for users whose addresses
+// the Session user can't see.
+func (session *Session) EnrichContacts(issues []Issue) error {
+	groups, err := session.cachedGroups()
+	if err != nil && !errors.Is(err, ErrForbidden) {
+		return err
+	}
+	isGroup := map[int]bool{}
+	for _, group := range groups {
+		isGroup[group.Id] = true
+	}
+
+	mail := func(id int) (string, error) {
+		if id == 0 || isGroup[id] {
+			return "", nil
+		}
+		user, err := session.cachedUser(id)
+		return user.Mail, err
+	}
+
+	for i := range issues {
+		issue := &issues[i]
+		if issue.AuthorMail, err = mail(issue.Author.Id); err != nil {
+			return err
+		}
+		if issue.AssignedToMail, err = mail(issue.AssignedTo.Id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cachedIssuePriorities returns the available issue priorities, only
+// requesting them from Redmine the first time they're needed.
+func (session *Session) cachedIssuePriorities() ([]IssuePriority, error) {
+	session.cache.Lock()
+	priorities := session.cache.priorities
+	session.cache.Unlock()
+	if priorities != nil {
+		return priorities, nil
+	}
+
+	priorities, err := session.GetIssuePriorities()
+	if err != nil {
+		return nil, err
+	}
+
+	session.cache.Lock()
+	session.cache.priorities = priorities
+	session.cache.Unlock()
+	return priorities, nil
+}
+
+// cachedTrackers returns the available trackers, only requesting them from
+// Redmine the first time they're needed.
+func (session *Session) cachedTrackers() ([]Tracker, error) {
+	session.cache.Lock()
+	trackers := session.cache.trackers
+	session.cache.Unlock()
+	if trackers != nil {
+		return trackers, nil
+	}
+
+	trackers, err := session.GetTrackers()
+	if err != nil {
+		return nil, err
+	}
+
+	session.cache.Lock()
+	session.cache.trackers = trackers
+	session.cache.Unlock()
+	return trackers, nil
+}
+
+// cachedCustomFields returns the custom field definitions, only requesting
+// them from Redmine the first time they're needed.
+func (session *Session) cachedCustomFields() ([]CustomField, error) {
+	session.cache.Lock()
+	fields := session.cache.customFields
+	session.cache.Unlock()
+	if fields != nil {
+		return fields, nil
+	}
+
+	fields, err := session.GetCustomFields()
+	if err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		fields = []CustomField{}
+	}
+
+	session.cache.Lock()
+	session.cache.customFields = fields
+	session.cache.Unlock()
+	return fields, nil
+}
+
+// userName returns the display name of a user, which is empty if the user
+// can't be seen by the Session user.
+func (session *Session) userName(id int) (string, error) {
+	user, err := session.cachedUser(id)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(user.Firstname + " " + user.Lastname), nil
+}
+
+// cachedUser returns a user. All users are listed at once when possible,
+// which requires administrator privileges; otherwise each user is looked up
+// in Redmine the first time they're needed. A user that can't be seen by the
+// Session user has only an Id.
+func (session *Session) cachedUser(id int) (User, error) {
+	if err := session.listUsers(); err != nil {
+		return User{}, err
+	}
+
+	session.cache.Lock()
+	user, ok := session.cache.users[id]
+	session.cache.Unlock()
+	if ok {
+		return user, nil
+	}
+
+	user, err := session.GetUserById(id)
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrForbidden) {
+		user = User{Id: id}
+	} else if err != nil {
+		return User{}, err
+	}
+
+	session.cache.Lock()
+	session.cache.users[id] = user
+	session.cache.Unlock()
+	return user, nil
+}
+
+// listUsers caches all users, of any status, if they haven't been listed in
+// the last userListTTL. If the Session user isn't allowed to list users, the
+// cache is left as it is.
+func (session *Session) listUsers() error {
+	session.cache.Lock()
+	fresh := time.Since(session.cache.usersListed) < userListTTL
+	session.cache.Unlock()
+	if fresh {
+		return nil
+	}
+
+	users, err := session.GetUsers(map[string]string{"status": ""})
+	if err != nil && !errors.Is(err, ErrForbidden) {
+		return err
+	}
+
+	session.cache.Lock()
+	defer session.cache.Unlock()
+	if err == nil {
+		session.cache.users = map[int]User{}
+		for _, user := range users {
+			session.cache.users[user.Id] = user
+		}
+	}
+	session.cache.usersListed = time.Now()
+	return nil
+}
@@ -0,0 +1,125 @@
+package redmine
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// newUserServer returns a server with users 1 to count and no groups, and the
+// number of requests it has received. Listing the users or groups is
+// forbidden unless admin is true.
+func newUserServer(count int, admin bool) (*httptest.Server, *int64) {
+	var requests int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.URL.Path == "/groups.json" {
+			if !admin {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			fmt.Fprint(w, `{"groups":[],"total_count":0,"offset":0,"limit":100}`)
+			return
+		}
+		if r.URL.Path == "/users.json" {
+			if !admin {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			users := make([]string, count)
+			for i := range users {
+				users[i] = fmt.Sprintf(`{"id":%d,"firstname":"User","lastname":"%d"}`, i+1, i+1)
+			}
+			fmt.Fprintf(w, `{"users":[%s],"total_count":%d,"offset":0,"limit":100}`,
+				strings.Join(users, ","), count)
+			return
+		}
+
+		id, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/users/"), ".json"))
+		if err != nil || id < 1 || id > count {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"user":{"id":%d,"firstname":"User","lastname":"%d"}}`, id, id)
+	}))
+	return server, &requests
+}
+
+func TestResolveNamesReturnsServerErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	session := OpenSession(server.URL, "key")
+	err := session.ResolveNames([]Issue{{Id: 1, Author: Identifier{Id: 3}}})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("got error %v, want a 500 APIError", err)
+	}
+}
+
+func TestEnrichContactsCachesForbiddenGroups(t *testing.T) {
+	server, requests := newUserServer(2, false)
+	defer server.Close()
+
+	session := OpenSession(server.URL, "key")
+	issues := []Issue{{Id: 1, Author: Identifier{Id: 1}, AssignedTo: Identifier{Id: 2}}}
+	if err := session.EnrichContacts(issues); err != nil {
+		t.Fatal(err)
+	}
+
+	// The groups, the users, and users 1 and 2 are requested the first time,
+	// and nothing is requested the second time
+	if got := atomic.LoadInt64(requests); got != 4 {
+		t.Errorf("made %d requests, want 4", got)
+	}
+	if err := session.EnrichContacts(issues); err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt64(requests); got != 4 {
+		t.Errorf("made %d requests after enriching again, want 4", got)
+	}
+}
+
+func benchmarkResolveNames(b *testing.B, admin bool) {
+	const users = 50
+	server, requests := newUserServer(users, admin)
+	defer server.Close()
+
+	issues := make([]Issue, 200)
+	for i := range issues {
+		issues[i] = Issue{Id: i + 1, Author: Identifier{Id: i%users + 1}}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		session := OpenSession(server.URL, "key")
+		resolved := append([]Issue(nil), issues...)
+		if err := session.ResolveNames(resolved); err != nil {
+			b.Fatal(err)
+		}
+		if resolved[0].Author.Name != "User 1" {
+			b.Fatalf("got author name %q, want %q", resolved[0].Author.Name, "User 1")
+		}
+	}
+	b.ReportMetric(float64(atomic.LoadInt64(requests))/float64(b.N), "requests/op")
+}
+
+// BenchmarkResolveNamesListed resolves names by listing all users at once.
+func BenchmarkResolveNamesListed(b *testing.B) {
+	benchmarkResolveNames(b, true)
+}
+
+// BenchmarkResolveNamesPerUser resolves names by looking up each user, as
+// happens when the Session user can't list users.
+func BenchmarkResolveNamesPerUser(b *testing.B) {
+	benchmarkResolveNames(b, false)
+}
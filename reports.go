@@ -0,0 +1,75 @@
+package redmine
+
+import (
+	"sort"
+	"strconv"
+	"time"
+)
+
+// SpentHoursByIssue returns the hours spent on each issue in a project between
+// two dates, inclusive, keyed by issue id. Hours logged against the project
+// rather than an issue are under the key 0.
+func (session *Session) SpentHoursByIssue(projectId int, from, to time.Time) (map[int]float64, error) {
+	entries, err := session.unpaged().TimeEntriesBetween(from, to, map[string]string{
+		"project_id": strconv.Itoa(projectId)})
+	if err != nil {
+		return nil, err
+	}
+
+	hours := map[int]float64{}
+	for _, entry := range entries {
+		hours[entry.Issue.Id] += entry.Hours
+	}
+	return hours, nil
+}
+
+// TimesheetRow holds the hours a user spent on each day of a timesheet, keyed
+// by date, in the form "2006-01-02".
+type TimesheetRow struct {
+	User Identifier
+	Days map[string]float64
+}
+
+// TimesheetMatrix returns the hours spent by a user, or by every user if
+// userId is 0, on each day between two dates, inclusive. There's a row for
+// each user who logged time, ordered by name and then by id, since several
+// users can have the same name. Every day in the range is present in each
+// row, even if it's zero. The days run from the calendar date of from to that
+// of to, each taken in its own location rather than in UTC, which matches the
+// dates TimeEntriesBetween requests.
+func (session *Session) TimesheetMatrix(userId int, from, to time.Time) ([]TimesheetRow, error) {
+	filter := map[string]string{}
+	if userId != 0 {
+		filter["user_id"] = strconv.Itoa(userId)
+	}
+	entries, err := session.unpaged().TimeEntriesBetween(from, to, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	byUser := map[int]int{}
+	var rows []TimesheetRow
+	for _, entry := range entries {
+		i, ok := byUser[entry.User.Id]
+		if !ok {
+			days := map[string]float64{}
+			first := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+			last := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+			for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+				days[day.Format("2006-01-02")] = 0
+			}
+			i = len(rows)
+			byUser[entry.User.Id] = i
+			rows = append(rows, TimesheetRow{User: entry.User, Days: days})
+		}
+		rows[i].Days[entry.SpentOn] += entry.Hours
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].User.Name != rows[j].User.Name {
+			return rows[i].User.Name < rows[j].User.Name
+		}
+		return rows[i].User.Id < rows[j].User.Id
+	})
+	return rows, nil
+}
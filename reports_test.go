@@ -0,0 +1,79 @@
+package redmine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimesheetMatrixSameNames(t *testing.T) {
+	session := NewFixtureSession(Fixtures{
+		"/time_entries.json": `{"time_entries":[
+			{"id":1,"hours":2,"spent_on":"2024-03-04","user":{"id":3,"name":"Ann Lee"}},
+			{"id":2,"hours":1,"spent_on":"2024-03-04","user":{"id":4,"name":"Ann Lee"}},
+			{"id":3,"hours":3,"spent_on":"2024-03-05","user":{"id":3,"name":"Ann Lee"}}],
+			"total_count":3}`,
+	})
+
+	from := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	rows, err := session.TimesheetMatrix(0, from, from.AddDate(0, 0, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(rows))
+	}
+	if rows[0].User.Id != 3 || rows[1].User.Id != 4 {
+		t.Fatalf("got users %+v and %+v, want 3 and 4", rows[0].User, rows[1].User)
+	}
+	if got := rows[0].Days; got["2024-03-04"] != 2 || got["2024-03-05"] != 3 || got["2024-03-06"] != 0 || len(got) != 3 {
+		t.Errorf("got hours %v for user 3", got)
+	}
+	if got := rows[1].Days; got["2024-03-04"] != 1 || len(got) != 3 {
+		t.Errorf("got hours %v for user 4", got)
+	}
+}
+
+func TestTimesheetMatrixLocalDates(t *testing.T) {
+	session := NewFixtureSession(Fixtures{
+		"/time_entries.json": `{"time_entries":[
+			{"id":1,"hours":2,"spent_on":"2024-03-04","user":{"id":3,"name":"Ann Lee"}}],
+			"total_count":1}`,
+	})
+
+	// These are 2024-03-03 and 2024-03-04 in UTC
+	tokyo := time.FixedZone("JST", 9*60*60)
+	from := time.Date(2024, 3, 4, 8, 0, 0, 0, tokyo)
+	rows, err := session.TimesheetMatrix(3, from, from.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("got %d rows, want 1", len(rows))
+	}
+	days := rows[0].Days
+	if _, ok := days["2024-03-03"]; ok || len(days) != 2 || days["2024-03-04"] != 2 {
+		t.Errorf("got days %v, want 2024-03-04 and 2024-03-05", days)
+	}
+}
+
+func TestSpentHoursByIssueIgnoresPageLimit(t *testing.T) {
+	server := newPagingServer("time_entries", []string{
+		`{"id":1,"hours":2,"issue":{"id":7}}`,
+		`{"id":2,"hours":1.5,"issue":{"id":7}}`,
+		`{"id":3,"hours":4}`})
+	defer server.Close()
+
+	session := OpenSession(server.URL, "key")
+	session.SetPageSize(1)
+	session.SetMaxPages(1)
+
+	from := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	hours, err := session.SpentHoursByIssue(1, from, from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hours[7] != 3.5 || hours[0] != 4 {
+		t.Errorf("got hours %v, want 3.5 on issue 7 and 4 on the project", hours)
+	}
+}
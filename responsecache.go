@@ -0,0 +1,62 @@
+package redmine
+
+import (
+	"sync"
+)
+
+// CachedResponse is the body of a response stored in a ResponseCache, along
+// with the validators Redmine sent with it.
+type CachedResponse struct {
+	ETag         string
+	LastModified string
+	Body         []byte
+}
+
+// ResponseCache stores the bodies of responses to GET requests so that a
+// Session can make conditional requests and reuse a body when Redmine reports
+// it hasn't changed. Keys are request URLs, followed by the impersonated user
+// if any; a cache shouldn't be shared by Sessions for different users.
+// Implementations must be safe for concurrent use.
+type ResponseCache interface {
+	Get(key string) (CachedResponse, bool)
+	Set(key string, response CachedResponse)
+}
+
+// NewMemoryResponseCache returns a ResponseCache that keeps responses in
+// memory. It never evicts responses.
+func NewMemoryResponseCache() ResponseCache {
+	return &memoryResponseCache{responses: map[string]CachedResponse{}}
+}
+
+type memoryResponseCache struct {
+	sync.Mutex
+	responses map[string]CachedResponse
+}
+
+func (cache *memoryResponseCache) Get(key string) (CachedResponse, bool) {
+	cache.Lock()
+	defer cache.Unlock()
+	response, ok := cache.responses[key]
+	return response, ok
+}
+
+func (cache *memoryResponseCache) Set(key string, response CachedResponse) {
+	cache.Lock()
+	defer cache.Unlock()
+	cache.responses[key] = response
+}
+
+// SetResponseCache sets a cache that a Session uses to make conditional GET
+// requests with the ETag and Last-Modified headers Redmine sends. Only
+// responses that have one of these headers are cached.
+func (session *Session) SetResponseCache(cache ResponseCache) {
+	session.responses = cache
+}
+
+// responseCacheKey returns the key a GET request's response is cached under.
+func (session *Session) responseCacheKey(requestUrl string) string {
+	if session.switchUser != "" {
+		return requestUrl + " " + session.switchUser
+	}
+	return requestUrl
+}
@@ -0,0 +1,85 @@
+package redmine
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Redmine returns timestamps, such as an issue's CreatedOn, in UTC in the
+// form "2006-01-02T15:04:05Z", and dates, such as a time entry's SpentOn, as
+// bare calendar dates in the form "2006-01-02". A date has no time zone; it's
+// the date in whatever time zone the user who entered it was in, so it should
+// be interpreted in the location a report is for rather than converted from
+// UTC. RedmineTime and Session.ParseTime handle both forms.
+
+// RedmineTime is a timestamp or date read from Redmine. A date is stored as
+// midnight UTC on that date, with IsDate set.
+type RedmineTime struct {
+	time.Time
+	IsDate bool
+}
+
+// ParseTime parses a timestamp or date returned by Redmine. An empty string
+// parses as the zero time.
+func ParseTime(value string) (RedmineTime, error) {
+	if value == "" {
+		return RedmineTime{}, nil
+	}
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return RedmineTime{Time: date, IsDate: true}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return RedmineTime{Time: t}, err
+}
+
+// UnmarshalJSON decodes a RedmineTime from a JSON string or null.
+func (t *RedmineTime) UnmarshalJSON(data []byte) error {
+	var value *string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	if value == nil {
+		*t = RedmineTime{}
+		return nil
+	}
+
+	parsed, err := ParseTime(*value)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// In returns a RedmineTime as a time in loc. A date becomes midnight in loc
+// on the same calendar date; a timestamp is converted to loc.
+func (t RedmineTime) In(loc *time.Location) time.Time {
+	if t.IsDate {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	}
+	return t.Time.In(loc)
+}
+
+// SetLocation sets the location that Session.ParseTime returns times in. It
+// defaults to the local time zone. Only Session.ParseTime uses it: the
+// timestamps and dates of decoded items, such as Issue.CreatedOn, are kept as
+// the strings Redmine returns, and a RedmineTime decoded from JSON is in UTC,
+// so they're put in the location by passing them to Session.ParseTime.
+func (session *Session) SetLocation(loc *time.Location) {
+	session.location = loc
+}
+
+// ParseTime parses a timestamp or date returned by Redmine as a time in the
+// Session's location.
+func (session *Session) ParseTime(value string) (time.Time, error) {
+	t, err := ParseTime(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	loc := session.location
+	if loc == nil {
+		loc = time.Local
+	}
+	return t.In(loc), nil
+}
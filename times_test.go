@@ -0,0 +1,35 @@
+package redmine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionParseTimeLocation(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	session := NewFixtureSession(Fixtures{})
+	session.SetLocation(tokyo)
+
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		// A timestamp is converted to the location, which changes its date
+		{"2024-03-04T20:30:00Z", time.Date(2024, 3, 5, 5, 30, 0, 0, tokyo)},
+		// A date keeps its calendar date
+		{"2024-03-04", time.Date(2024, 3, 4, 0, 0, 0, 0, tokyo)},
+	}
+	for _, test := range tests {
+		got, err := session.ParseTime(test.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Equal(test.want) || got.Location() != tokyo {
+			t.Errorf("got %v for %s, want %v", got, test.value, test.want)
+		}
+	}
+
+	if parsed, _ := ParseTime("2024-03-04T20:30:00Z"); parsed.Location() != time.UTC {
+		t.Errorf("got location %v from ParseTime, want UTC", parsed.Location())
+	}
+}
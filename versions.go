@@ -0,0 +1,170 @@
+package redmine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Version represents a project version (milestone) in Redmine. Sharing is
+// one of "none", "descendants", "hierarchy", "tree", or "system".
+type Version struct {
+	Id          int        `json:"id"`
+	Project     Identifier `json:"project"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Status      string     `json:"status"`
+	Sharing     string     `json:"sharing"`
+	DueDate     string     `json:"due_date"`
+	CreatedOn   string     `json:"created_on"`
+	UpdatedOn   string     `json:"updated_on"`
+}
+
+// IsInherited reports whether a version is shared into projectId from
+// another project rather than belonging to it.
+func (version Version) IsInherited(projectId int) bool {
+	return version.Project.Id != projectId
+}
+
+// UpdateVersion is used to pass version updates to Redmine. Status may be
+// "open", "locked", or "closed".
+type UpdateVersion struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	Status      string `json:"status,omitempty"`
+	Sharing     string `json:"sharing,omitempty"`
+	DueDate     string `json:"due_date,omitempty"`
+}
+
+// GetVersions returns the versions available to a project, including those
+// shared with it by other projects.
+func (session *Session) GetVersions(projectId int) ([]Version, error) {
+	var versions []Version
+	path := "/projects/" + strconv.Itoa(projectId) + "/versions.json"
+	if err := session.getAll(path, nil, "versions", &versions); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// GetOpenVersions returns a project's open versions, ordered by due date.
+// Versions without a due date come last.
+func (session *Session) GetOpenVersions(projectId int) ([]Version, error) {
+	versions, err := session.GetVersions(projectId)
+	if err != nil {
+		return nil, err
+	}
+
+	versions = FilterVersionsByStatus(versions, "open")
+	sortByDueDate(versions)
+	return versions, nil
+}
+
+// VersionWithIssues is a version along with the issues targeted at it, keyed
+// by the name of their status.
+type VersionWithIssues struct {
+	Version
+	Issues map[string][]Issue
+}
+
+// GetRoadmap returns a project's open and locked versions, ordered by due
+// date, along with the issues in the project targeted at each, like Redmine's
+// roadmap. If includeClosed is true, closed versions are included as well.
+func (session *Session) GetRoadmap(projectId int, includeClosed bool) ([]VersionWithIssues, error) {
+	versions, err := session.GetVersions(projectId)
+	if err != nil {
+		return nil, err
+	}
+	if !includeClosed {
+		versions = FilterVersionsByStatus(versions, "open", "locked")
+	}
+	sortByDueDate(versions)
+
+	roadmap := make([]VersionWithIssues, len(versions))
+	errs := make([]error, len(versions))
+	session.forEach(len(versions), func(i int) {
+		var issues []Issue
+		issues, errs[i] = session.unpaged().GetIssuesWithFilter(map[string]string{
+			"project_id":       strconv.Itoa(projectId),
+			"fixed_version_id": strconv.Itoa(versions[i].Id),
+			"status_id":        StatusAll,
+		})
+
+		roadmap[i] = VersionWithIssues{Version: versions[i], Issues: map[string][]Issue{}}
+		for _, issue := range issues {
+			roadmap[i].Issues[issue.Status.Name] = append(roadmap[i].Issues[issue.Status.Name], issue)
+		}
+	})
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return roadmap, nil
+}
+
+// sortByDueDate sorts versions by due date, with versions without a due date
+// last.
+func sortByDueDate(versions []Version) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		a, b := versions[i].DueDate, versions[j].DueDate
+		if a == "" || b == "" {
+			return b == "" && a != ""
+		}
+		return a < b
+	})
+}
+
+// FilterVersionsByStatus returns the versions having one of the given
+// statuses ("open", "locked", or "closed"). Redmine returns versions of every
+// status and can't filter them itself.
+func FilterVersionsByStatus(versions []Version, statuses ...string) []Version {
+	filtered := []Version{}
+	for _, version := range versions {
+		for _, status := range statuses {
+			if version.Status == status {
+				filtered = append(filtered, version)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// GetVersion returns a specific version.
+func (session *Session) GetVersion(id int) (version Version, err error) {
+	var data []byte
+	if data, err = session.get("/versions/"+strconv.Itoa(id)+".json", nil); err != nil {
+		return
+	}
+
+	var v struct {
+		Version Version `json:"version"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err = dec.Decode(&v); err != nil {
+		return
+	}
+	version = v.Version
+	return
+}
+
+// UpdateVersion updates a version, such as to rename or close it.
+func (session *Session) UpdateVersion(id int, version UpdateVersion) error {
+	data := map[string]interface{}{
+		"version": version,
+	}
+	_, err := session.put("/versions/"+strconv.Itoa(id)+".json", data)
+	return err
+}
+
+// DeleteVersion deletes a version. Redmine won't delete a version that issues
+// are still assigned to.
+func (session *Session) DeleteVersion(id int) error {
+	if _, err := session.delete("/versions/" + strconv.Itoa(id) + ".json"); err != nil {
+		return fmt.Errorf("unable to delete version %d: %w", id, err)
+	}
+	return nil
+}
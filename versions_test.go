@@ -0,0 +1,28 @@
+package redmine
+
+import (
+	"testing"
+)
+
+func TestGetVersionsShared(t *testing.T) {
+	session := NewFixtureSession(Fixtures{
+		"/projects/2/versions.json": `{"versions":[
+			{"id":1,"project":{"id":2,"name":"Child"},"name":"1.0","status":"open","sharing":"none"},
+			{"id":2,"project":{"id":1,"name":"Parent"},"name":"2.0","status":"open","sharing":"descendants"}],
+			"total_count":2}`,
+	})
+
+	versions, err := session.GetVersions(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("got %d versions, want 2", len(versions))
+	}
+	if versions[0].Sharing != "none" || versions[0].IsInherited(2) {
+		t.Errorf("version 1.0 has sharing %q and is inherited: %v", versions[0].Sharing, versions[0].IsInherited(2))
+	}
+	if versions[1].Sharing != "descendants" || !versions[1].IsInherited(2) {
+		t.Errorf("version 2.0 has sharing %q and is inherited: %v", versions[1].Sharing, versions[1].IsInherited(2))
+	}
+}
@@ -0,0 +1,76 @@
+package redmine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// WikiPage represents a page of a project's wiki. Text, Author, and Comments
+// aren't set for pages returned by GetWikiPages.
+type WikiPage struct {
+	Title  string `json:"title"`
+	Parent struct {
+		Title string `json:"title"`
+	} `json:"parent"`
+	Text      string     `json:"text"`
+	Version   int        `json:"version"`
+	Author    Identifier `json:"author"`
+	Comments  string     `json:"comments"`
+	CreatedOn string     `json:"created_on"`
+	UpdatedOn string     `json:"updated_on"`
+}
+
+// GetWikiPages returns an index of all the pages in a project's wiki. The
+// project is given by its id or identifier.
+func (session *Session) GetWikiPages(projectId string) ([]WikiPage, error) {
+	data, err := session.get("/projects/"+url.PathEscape(projectId)+"/wiki/index.json", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var index struct {
+		WikiPages []WikiPage `json:"wiki_pages"`
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	err = dec.Decode(&index)
+	if err != nil {
+		return nil, err
+	}
+
+	return index.WikiPages, nil
+}
+
+// GetWikiPage returns the current version of a page in a project's wiki.
+func (session *Session) GetWikiPage(projectId string, title string) (page WikiPage, err error) {
+	var data []byte
+	if data, err = session.get(wikiPagePath(projectId, title), nil); err != nil {
+		return
+	}
+
+	var p struct {
+		WikiPage WikiPage `json:"wiki_page"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err = dec.Decode(&p); err != nil {
+		return
+	}
+	page = p.WikiPage
+	return
+}
+
+// DeleteWikiPage deletes a page from a project's wiki, along with its history
+// and attachments. Child pages of a deleted page become top-level pages.
+func (session *Session) DeleteWikiPage(projectId string, title string) error {
+	if _, err := session.delete(wikiPagePath(projectId, title)); err != nil {
+		return fmt.Errorf("unable to delete wiki page %s: %w", title, err)
+	}
+	return nil
+}
+
+// wikiPagePath returns the API path of a wiki page.
+func wikiPagePath(projectId string, title string) string {
+	return "/projects/" + url.PathEscape(projectId) + "/wiki/" + url.PathEscape(title) + ".json"
+}